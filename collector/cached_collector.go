@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	snapshotAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("gcp", "scrape", "snapshot_age_seconds"),
+		"gcp_idleness_exporter: Seconds since the served metrics snapshot was collected.",
+		nil,
+		nil,
+	)
+	snapshotRefreshDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("gcp", "scrape", "snapshot_refresh_duration_seconds"),
+		"gcp_idleness_exporter: Duration of the last metrics snapshot refresh.",
+		nil,
+		nil,
+	)
+)
+
+// CachedCollector implements the prometheus.Collector interface by serving the
+// last complete set of metrics gathered in the background, so that scrapes
+// never trigger calls to the GCP APIs.
+type CachedCollector struct {
+	newCollector func() (prometheus.Collector, error)
+	interval     time.Duration
+	logger       log.Logger
+
+	mutex           sync.RWMutex
+	metrics         []prometheus.Metric
+	refreshedAt     time.Time
+	refreshDuration time.Duration
+}
+
+// NewCachedCollector creates a CachedCollector which refreshes its snapshot every
+// interval with the metrics of the collector returned by newCollector.
+func NewCachedCollector(logger log.Logger, interval time.Duration, newCollector func() (prometheus.Collector, error)) *CachedCollector {
+	return &CachedCollector{
+		newCollector: newCollector,
+		interval:     interval,
+		logger:       logger,
+	}
+}
+
+// Run refreshes the snapshot right away and then on every interval until ctx is done.
+func (c *CachedCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Refresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh collects a new snapshot and swaps it in once it is complete.
+func (c *CachedCollector) Refresh() {
+	begin := time.Now()
+	collector, err := c.newCollector()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "couldn't create collector, keeping previous snapshot", "err", err)
+		return
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+
+	metrics := []prometheus.Metric{}
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	duration := time.Since(begin)
+
+	c.mutex.Lock()
+	c.metrics = metrics
+	c.refreshedAt = time.Now()
+	c.refreshDuration = duration
+	c.mutex.Unlock()
+
+	level.Debug(c.logger).Log("msg", "metrics snapshot refreshed", "metrics", len(metrics), "duration_seconds", duration.Seconds())
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *CachedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- snapshotAgeDesc
+	ch <- snapshotRefreshDurationDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *CachedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.refreshedAt.IsZero() {
+		// No snapshot has been completed yet.
+		return
+	}
+
+	for _, m := range c.metrics {
+		ch <- m
+	}
+	ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, time.Since(c.refreshedAt).Seconds())
+	ch <- prometheus.MustNewConstMetric(snapshotRefreshDurationDesc, prometheus.GaugeValue, c.refreshDuration.Seconds())
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var fakeDesc = prometheus.NewDesc("fake_metric", "fake metric", nil, nil)
+
+type fakeCollector struct{}
+
+func (f fakeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fakeDesc
+}
+
+func (f fakeCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(fakeDesc, prometheus.GaugeValue, 1)
+}
+
+func collectAll(c prometheus.Collector) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	metrics := []prometheus.Metric{}
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestCachedCollectorCollect(t *testing.T) {
+	fail := false
+	c := NewCachedCollector(log.NewJSONLogger(os.Stdout), time.Minute, func() (prometheus.Collector, error) {
+		if fail {
+			return nil, errors.New("boom")
+		}
+		return fakeCollector{}, nil
+	})
+
+	if got := len(collectAll(c)); got != 0 {
+		t.Errorf("expected no metrics before the first refresh, got %d", got)
+	}
+
+	c.Refresh()
+	if got := len(collectAll(c)); got != 3 {
+		t.Errorf("expected 3 metrics after refresh, got %d", got)
+	}
+
+	fail = true
+	c.Refresh()
+	if got := len(collectAll(c)); got != 3 {
+		t.Errorf("expected previous snapshot to be kept on failure, got %d metrics", got)
+	}
+}
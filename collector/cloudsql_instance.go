@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+)
+
+var (
+	isCloudSQLInstanceRunning  = prometheus.NewDesc("cloudsql_instance_is_running", "tells whether the Cloud SQL instance is running", []string{"project", "region", "name"}, nil)
+	cloudSQLInstanceInfo       = prometheus.NewDesc("cloudsql_instance_info", "tells the activation policy, state and tier of the Cloud SQL instance", []string{"project", "region", "name", "activation_policy", "state", "tier", "database_version"}, nil)
+	cloudSQLInstanceDiskSizeGB = prometheus.NewDesc("cloudsql_instance_disk_size_gb", "tells the size of the Cloud SQL instance's data disk in GB", []string{"project", "region", "name"}, nil)
+	cloudSQLInstanceBackup     = prometheus.NewDesc("cloudsql_instance_backup_enabled", "tells whether automated backups of the Cloud SQL instance are kept, even when it is stopped", []string{"project", "region", "name"}, nil)
+)
+
+type CloudSQLInstanceCollector struct {
+	logger           log.Logger
+	service          *sqladmin.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("cloudsql_instance", defaultDisabled, NewCloudSQLInstanceCollector)
+}
+
+func (e *CloudSQLInstanceCollector) ListMetrics() []string {
+	return []string{"cloudsql_instance_is_running", "cloudsql_instance_info", "cloudsql_instance_disk_size_gb", "cloudsql_instance_backup_enabled", "cloudsql_instance_labels"}
+}
+
+func NewCloudSQLInstanceCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("cloudsql_instance", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, sqladmin.SqlserviceAdminScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	sqlService, err := sqladmin.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &CloudSQLInstanceCollector{
+		logger:           logger,
+		service:          sqlService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("cloudsql_instance", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("cloudsql_instance_labels", "GCP labels of the Cloud SQL instance", []string{"project", "region", "name"}),
+	}, nil
+}
+
+func (e *CloudSQLInstanceCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	// Instances of every region are listed at once, the API can't filter them by region.
+	instances := []*sqladmin.DatabaseInstance{}
+	err := e.service.Instances.List(e.project).Pages(context.Background(), func(page *sqladmin.InstancesListResponse) error {
+		e.pagesFetched.Inc()
+		for _, instance := range page.Items {
+			if lo.Contains(e.monitoredRegions, instance.Region) {
+				instances = append(instances, instance)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting Cloud SQL instances for project %s", e.project), "err", err)
+		return err
+	}
+
+	for _, instance := range instances {
+		e.report(ch, instance)
+	}
+
+	return nil
+}
+
+func (e *CloudSQLInstanceCollector) report(ch chan<- prometheus.Metric, instance *sqladmin.DatabaseInstance) {
+	settings := instance.Settings
+	if settings == nil {
+		settings = &sqladmin.Settings{}
+	}
+
+	if e.excluder.Excluded(instance.Name, settings.UserLabels) {
+		return
+	}
+
+	var isRunning float64
+	if instance.State == "RUNNABLE" && settings.ActivationPolicy == "ALWAYS" {
+		isRunning = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(isCloudSQLInstanceRunning, prometheus.GaugeValue, isRunning, e.project, instance.Region, instance.Name)
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudSQLInstanceInfo,
+		prometheus.GaugeValue,
+		1.0,
+		e.project,
+		instance.Region,
+		instance.Name,
+		settings.ActivationPolicy,
+		instance.State,
+		settings.Tier,
+		instance.DatabaseVersion)
+
+	ch <- prometheus.MustNewConstMetric(cloudSQLInstanceDiskSizeGB, prometheus.GaugeValue, float64(settings.DataDiskSizeGb), e.project, instance.Region, instance.Name)
+
+	var backupEnabled float64
+	if settings.BackupConfiguration != nil && settings.BackupConfiguration.Enabled {
+		backupEnabled = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(cloudSQLInstanceBackup, prometheus.GaugeValue, backupEnabled, e.project, instance.Region, instance.Name)
+
+	e.labelsInfo.Collect(ch, settings.UserLabels, e.project, instance.Region, instance.Name)
+}
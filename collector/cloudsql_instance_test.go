@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+)
+
+func TestCloudSQLInstanceCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for cloudsql_instance collector", []string{"cloudsql_instance_is_running", "cloudsql_instance_info", "cloudsql_instance_disk_size_gb", "cloudsql_instance_backup_enabled", "cloudsql_instance_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := CloudSQLInstanceCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestCloudSQLInstanceReport(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *sqladmin.DatabaseInstance
+		expected map[string]float64
+	}{
+		{
+			"should report a running instance with backups",
+			&sqladmin.DatabaseInstance{
+				Name:  "db",
+				State: "RUNNABLE",
+				Settings: &sqladmin.Settings{
+					ActivationPolicy:    "ALWAYS",
+					DataDiskSizeGb:      100,
+					BackupConfiguration: &sqladmin.BackupConfiguration{Enabled: true},
+				},
+			},
+			map[string]float64{"cloudsql_instance_is_running/db": 1, "cloudsql_instance_info/db": 1, "cloudsql_instance_disk_size_gb/db": 100, "cloudsql_instance_backup_enabled/db": 1},
+		},
+		{
+			"should report a stopped instance which keeps its disk and backups",
+			&sqladmin.DatabaseInstance{
+				Name:  "db",
+				State: "RUNNABLE",
+				Settings: &sqladmin.Settings{
+					ActivationPolicy:    "NEVER",
+					DataDiskSizeGb:      250,
+					BackupConfiguration: &sqladmin.BackupConfiguration{Enabled: true},
+				},
+			},
+			map[string]float64{"cloudsql_instance_is_running/db": 0, "cloudsql_instance_info/db": 1, "cloudsql_instance_disk_size_gb/db": 250, "cloudsql_instance_backup_enabled/db": 1},
+		},
+		{
+			"should report a suspended instance without backups",
+			&sqladmin.DatabaseInstance{
+				Name:     "db",
+				State:    "SUSPENDED",
+				Settings: &sqladmin.Settings{ActivationPolicy: "ALWAYS", DataDiskSizeGb: 10},
+			},
+			map[string]float64{"cloudsql_instance_is_running/db": 0, "cloudsql_instance_info/db": 1, "cloudsql_instance_disk_size_gb/db": 10, "cloudsql_instance_backup_enabled/db": 0},
+		},
+		{
+			"should report an instance without settings",
+			&sqladmin.DatabaseInstance{Name: "db", State: "RUNNABLE"},
+			map[string]float64{"cloudsql_instance_is_running/db": 0, "cloudsql_instance_info/db": 1, "cloudsql_instance_disk_size_gb/db": 0, "cloudsql_instance_backup_enabled/db": 0},
+		},
+	}
+
+	for _, tc := range cases {
+		collector := CloudSQLInstanceCollector{project: "project"}
+		r := gatherGauges(t, func(ch chan<- prometheus.Metric) { collector.report(ch, tc.input) })
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
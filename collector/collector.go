@@ -18,27 +18,38 @@ var (
 	scrapeDurationDesc = prometheus.NewDesc(
 		prometheus.BuildFQName("gcp", "scrape", "collector_duration_seconds"),
 		"gcp_idleness_exporter: Duration of a collector scrape.",
-		[]string{"collector"},
+		[]string{"collector", "project"},
 		nil,
 	)
 	scrapeSuccessDesc = prometheus.NewDesc(
 		prometheus.BuildFQName("gcp", "scrape", "collector_success"),
 		"gcp_idleness_exporter: Whether a collector succeeded.",
-		[]string{"collector"},
+		[]string{"collector", "project"},
 		nil,
 	)
+	apiPagesFetched = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gcp",
+			Subsystem: "scrape",
+			Name:      "api_pages_fetched_total",
+			Help:      "gcp_idleness_exporter: Number of GCP API result pages fetched by a collector.",
+		},
+		[]string{"collector", "project"},
+	)
 )
 
 const (
-	defaultEnabled = true
+	defaultEnabled  = true
+	defaultDisabled = false
 )
 
 var (
 	factories              = make(map[string]func(logger log.Logger, project string, monitoredRegions []string) (Collector, error))
 	initiatedCollectorsMtx = sync.Mutex{}
-	initiatedCollectors    = make(map[string]Collector)
+	initiatedCollectors    = make(map[string]map[string]Collector)
 	collectorState         = make(map[string]*bool)
-	forcedCollectors       = map[string]bool{} // collectors which have been explicitly enabled or disabled
+	forcedCollectors       = map[string]bool{}            // collectors which have been explicitly enabled or disabled
+	collectedProjects      = map[string]map[string]bool{} // projects the last GCPCollector was created for, by collector
 )
 
 func registerCollector(collector string, isDefaultEnabled bool, factory func(logger log.Logger, project string, monitoredRegions []string) (Collector, error)) {
@@ -57,11 +68,13 @@ func registerCollector(collector string, isDefaultEnabled bool, factory func(log
 	collectorState[collector] = flag
 
 	factories[collector] = factory
+	registerExclusionFlags(collector)
 }
 
 // GCPCollector implements the prometheus.Collector interface.
 type GCPCollector struct {
-	Collectors map[string]Collector
+	// Collectors holds the enabled collectors by name, then by project.
+	Collectors map[string]map[string]Collector
 	logger     log.Logger
 }
 
@@ -75,6 +88,21 @@ func DisableDefaultCollectors() {
 	}
 }
 
+// Reconfigure runs apply while no collector is being created and, if it succeeds,
+// drops every initiated collector so that they are created again with the
+// settings apply has changed.
+func Reconfigure(apply func() error) error {
+	initiatedCollectorsMtx.Lock()
+	defer initiatedCollectorsMtx.Unlock()
+
+	if err := apply(); err != nil {
+		return err
+	}
+
+	initiatedCollectors = make(map[string]map[string]Collector)
+	return nil
+}
+
 // collectorFlagAction generates a new action function for the given collector
 // to track whether it has been explicitly enabled or disabled from the command line.
 // A new action function is needed for each collector flag because the ParseContext
@@ -87,51 +115,87 @@ func collectorFlagAction(collector string) func(ctx *kingpin.ParseContext) error
 	}
 }
 
-// NewGCPCollector creates a new GCPCollector.
-func NewGCPCollector(ctx context.Context, logger log.Logger, project string, monitoredRedgions []string) (*GCPCollector, error) {
+// NewGCPCollector creates a new GCPCollector with one instance of every enabled
+// collector per project.
+func NewGCPCollector(ctx context.Context, logger log.Logger, projects []string, monitoredRedgions []string) (*GCPCollector, error) {
 	f := make(map[string]bool)
 
-	collectors := make(map[string]Collector)
+	collectors := make(map[string]map[string]Collector)
 	initiatedCollectorsMtx.Lock()
 	defer initiatedCollectorsMtx.Unlock()
 	for key, enabled := range collectorState {
 		if !*enabled || (len(f) > 0 && !f[key]) {
 			continue
 		}
-		if collector, ok := initiatedCollectors[key]; ok {
-			collectors[key] = collector
-		} else {
-			collector, err := factories[key](log.With(logger, "collector", key), project, monitoredRedgions)
-			if err != nil {
-				return nil, err
+		collectors[key] = make(map[string]Collector)
+		for _, project := range projects {
+			if collector, ok := initiatedCollectors[key][project]; ok {
+				collectors[key][project] = collector
+			} else {
+				collector, err := factories[key](log.With(logger, "collector", key, "project", project), project, monitoredRedgions)
+				if err != nil {
+					return nil, err
+				}
+				collectors[key][project] = collector
 			}
-			collectors[key] = collector
-			initiatedCollectors[key] = collector
 		}
+		// Projects which are no longer monitored are dropped along the way.
+		initiatedCollectors[key] = collectors[key]
 	}
+	forgetUncollectedProjects(collectors)
 	return &GCPCollector{Collectors: collectors, logger: logger}, nil
 }
 
+// forgetUncollectedProjects deletes the series of the projects which were
+// collected for until now but no longer are, e.g. once discovery drops them
+// or a reload disables their collector, so that they don't keep being exported.
+func forgetUncollectedProjects(collectors map[string]map[string]Collector) {
+	for name, projects := range collectedProjects {
+		for project := range projects {
+			if _, ok := collectors[name][project]; ok {
+				continue
+			}
+			labels := prometheus.Labels{"collector": name, "project": project}
+			apiPagesFetched.DeletePartialMatch(labels)
+			excludedResources.DeletePartialMatch(labels)
+		}
+	}
+
+	collectedProjects = make(map[string]map[string]bool, len(collectors))
+	for name, projectCollectors := range collectors {
+		collectedProjects[name] = make(map[string]bool, len(projectCollectors))
+		for project := range projectCollectors {
+			collectedProjects[name][project] = true
+		}
+	}
+}
+
 // Describe implements the prometheus.Collector interface.
 func (n GCPCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- scrapeDurationDesc
 	ch <- scrapeSuccessDesc
+	apiPagesFetched.Describe(ch)
+	excludedResources.Describe(ch)
 }
 
 // Collect implements the prometheus.Collector interface.
 func (n GCPCollector) Collect(ch chan<- prometheus.Metric) {
 	wg := sync.WaitGroup{}
-	wg.Add(len(n.Collectors))
-	for name, c := range n.Collectors {
-		go func(name string, c Collector) {
-			execute(name, c, ch, n.logger)
-			wg.Done()
-		}(name, c)
+	for name, projectCollectors := range n.Collectors {
+		wg.Add(len(projectCollectors))
+		for project, c := range projectCollectors {
+			go func(name string, project string, c Collector) {
+				execute(name, project, c, ch, n.logger)
+				wg.Done()
+			}(name, project, c)
+		}
 	}
 	wg.Wait()
+	apiPagesFetched.Collect(ch)
+	excludedResources.Collect(ch)
 }
 
-func execute(name string, c Collector, ch chan<- prometheus.Metric, logger log.Logger) {
+func execute(name string, project string, c Collector, ch chan<- prometheus.Metric, logger log.Logger) {
 	begin := time.Now()
 	err := c.Update(ch)
 	duration := time.Since(begin)
@@ -139,17 +203,17 @@ func execute(name string, c Collector, ch chan<- prometheus.Metric, logger log.L
 
 	if err != nil {
 		if IsNoDataError(err) {
-			level.Debug(logger).Log("msg", "collector returned no data", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			level.Debug(logger).Log("msg", "collector returned no data", "name", name, "project", project, "duration_seconds", duration.Seconds(), "err", err)
 		} else {
-			level.Error(logger).Log("msg", "collector failed", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			level.Error(logger).Log("msg", "collector failed", "name", name, "project", project, "duration_seconds", duration.Seconds(), "err", err)
 		}
 		success = 0
 	} else {
-		level.Debug(logger).Log("msg", "collector succeeded", "name", name, "duration_seconds", duration.Seconds())
+		level.Debug(logger).Log("msg", "collector succeeded", "name", name, "project", project, "duration_seconds", duration.Seconds())
 		success = 1
 	}
-	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
-	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name, project)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name, project)
 }
 
 // Collector is the interface a collector has to implement.
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestReconfigure(t *testing.T) {
+	cases := []struct {
+		desc     string
+		apply    func() error
+		err      bool
+		expected int
+	}{
+		{"should keep initiated collectors when the settings are invalid", func() error { return errors.New("invalid") }, true, 1},
+		{"should drop initiated collectors once the settings are applied", func() error { return nil }, false, 0},
+	}
+
+	defer func(c map[string]map[string]Collector) { initiatedCollectors = c }(initiatedCollectors)
+	for _, tc := range cases {
+		initiatedCollectors = map[string]map[string]Collector{"gce_image": {"project": &GCEImageCollector{}}}
+
+		err := Reconfigure(tc.apply)
+		if (err != nil) != tc.err || len(initiatedCollectors) != tc.expected {
+			t.Errorf("%s want error %v and %d collectors got %v and %d instead", tc.desc, tc.err, tc.expected, err, len(initiatedCollectors))
+		}
+	}
+}
+
+func TestForgetUncollectedProjects(t *testing.T) {
+	defer func(p map[string]map[string]bool) { collectedProjects = p }(collectedProjects)
+	collectedProjects = map[string]map[string]bool{
+		"gce_image":          {"kept-project": true, "dropped-project": true},
+		"gce_address_in_use": {"kept-project": true},
+	}
+	for _, series := range [][]string{{"gce_image", "kept-project"}, {"gce_image", "dropped-project"}, {"gce_address_in_use", "kept-project"}} {
+		apiPagesFetched.WithLabelValues(series...).Inc()
+		excludedResources.WithLabelValues(series...).Inc()
+	}
+
+	// gce_address_in_use got disabled and dropped-project is no longer monitored.
+	forgetUncollectedProjects(map[string]map[string]Collector{
+		"gce_image": {"kept-project": &GCEImageCollector{}, "new-project": &GCEImageCollector{}},
+	})
+
+	expected := map[string]map[string]bool{"gce_image": {"kept-project": true, "new-project": true}}
+	if !reflect.DeepEqual(collectedProjects, expected) {
+		t.Errorf("should track the collected projects want %v got %v instead", expected, collectedProjects)
+	}
+
+	cases := []struct {
+		desc     string
+		labels   prometheus.Labels
+		expected int
+	}{
+		{"should delete the series of the dropped project", prometheus.Labels{"collector": "gce_image", "project": "dropped-project"}, 0},
+		{"should delete the series of the disabled collector", prometheus.Labels{"collector": "gce_address_in_use", "project": "kept-project"}, 0},
+		{"should keep the series of the collected project", prometheus.Labels{"collector": "gce_image", "project": "kept-project"}, 1},
+	}
+
+	for _, tc := range cases {
+		if n := apiPagesFetched.DeletePartialMatch(tc.labels); n != tc.expected {
+			t.Errorf("%s want %d pages fetched series got %d instead", tc.desc, tc.expected, n)
+		}
+		if n := excludedResources.DeletePartialMatch(tc.labels); n != tc.expected {
+			t.Errorf("%s want %d excluded resources series got %d instead", tc.desc, tc.expected, n)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strings"
@@ -11,7 +12,10 @@ import (
 	"github.com/PuerkitoBio/rehttp"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/compute/v1"
 )
 
 func GetGCPZoneFromURL(logger log.Logger, z string) string {
@@ -34,6 +38,110 @@ func GetGCPZoneFromURL(logger log.Logger, z string) string {
 	return zone
 }
 
+func GetGCPRegionFromURL(logger log.Logger, r string) string {
+	u, err := url.Parse(r)
+	if err != nil {
+
+		level.Error(logger).Log("msg", "error parsing Region name", "err", err)
+		return ""
+	}
+
+	parts := strings.Split(u.Path, "/")
+
+	var region string
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "regions" {
+			region = parts[i+1]
+			i++
+		}
+	}
+
+	return region
+}
+
+func GetResourceNameFromURL(logger log.Logger, r string) string {
+	u, err := url.Parse(r)
+	if err != nil {
+
+		level.Error(logger).Log("msg", "error parsing resource name", "err", err)
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+
+	return parts[len(parts)-1]
+}
+
+// GetResourcePathFromURL returns the "projects/..." part of a resource URL so that
+// self links and references to the same resource can be compared.
+func GetResourcePathFromURL(logger log.Logger, r string) string {
+	u, err := url.Parse(r)
+	if err != nil {
+
+		level.Error(logger).Log("msg", "error parsing resource path", "err", err)
+		return ""
+	}
+
+	i := strings.Index(u.Path, "projects/")
+	if i < 0 {
+		return ""
+	}
+
+	return u.Path[i:]
+}
+
+// GetGCPRegionFromZone returns the region a zone belongs to, e.g. us-east1 for us-east1-b.
+func GetGCPRegionFromZone(zone string) string {
+	i := strings.LastIndex(zone, "-")
+	if i < 0 {
+		return zone
+	}
+
+	return zone[:i]
+}
+
+// GetGCPRegionFromLocation returns the region of a location which is either a
+// zone, e.g. us-east1-b, or a region, e.g. us-east1.
+func GetGCPRegionFromLocation(location string) string {
+	if strings.Count(location, "-") < 2 {
+		return location
+	}
+
+	return GetGCPRegionFromZone(location)
+}
+
+// GetGCPZoneFromScope returns the zone of an aggregated list scope such as
+// "zones/us-east1-b", or an empty string for regional and global scopes.
+func GetGCPZoneFromScope(scope string) string {
+	if !strings.HasPrefix(scope, "zones/") {
+		return ""
+	}
+
+	return strings.TrimPrefix(scope, "zones/")
+}
+
+// ListMonitoredZones returns the zones of the project which belong to the monitored regions.
+func ListMonitoredZones(ctx context.Context, logger log.Logger, service *compute.Service, project string, monitoredRegions []string, pagesFetched prometheus.Counter) ([]string, error) {
+	zones := []string{}
+	err := service.Regions.List(project).Pages(ctx, func(page *compute.RegionList) error {
+		pagesFetched.Inc()
+		for _, r := range page.Items {
+			if !lo.Contains(monitoredRegions, r.Name) {
+				continue
+			}
+			for _, z := range r.Zones {
+				zones = append(zones, GetGCPZoneFromURL(logger, z))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
 func GetDiskNameFromURL(logger log.Logger, z string) string {
 	u, err := url.Parse(z)
 	if err != nil {
@@ -54,6 +162,36 @@ func GetDiskNameFromURL(logger log.Logger, z string) string {
 	return disk
 }
 
+// unixTimestamp converts an RFC3339 timestamp of the GCP APIs into seconds since the epoch.
+func unixTimestamp(timestamp string) (float64, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(t.UnixNano()) / 1e9, nil
+}
+
+// secondsSince returns how many seconds have passed since an RFC3339 timestamp of the GCP APIs.
+func secondsSince(timestamp string, now time.Time) (float64, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return 0, err
+	}
+
+	return now.Sub(t).Seconds(), nil
+}
+
+// ageInDays returns how many whole days have passed since an RFC3339 timestamp of the GCP APIs.
+func ageInDays(timestamp string, now time.Time) (float64, error) {
+	seconds, err := secondsSince(timestamp, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Floor(seconds / (24 * 3600)), nil
+}
+
 var (
 	GCPHttpTimeout        time.Duration
 	GCPMaxRetries         int
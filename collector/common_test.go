@@ -3,8 +3,10 @@ package collector
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestGetGCPZoneFromURL(t *testing.T) {
@@ -58,3 +60,276 @@ func TestGetDiskNameFromURL(t *testing.T) {
 		}
 	}
 }
+
+func TestGetGCPRegionFromURL(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return empty",
+			"https://someurl/",
+			"",
+		},
+		{
+			"Should return us-east1",
+			"https://www.googleapis.com/compute/v1/projects/project-id/regions/us-east1",
+			"us-east1",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetGCPRegionFromURL(log.NewJSONLogger(os.Stdout), tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGetResourceNameFromURL(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return empty",
+			"https://someurl/",
+			"",
+		},
+		{
+			"Should return vm",
+			"https://www.googleapis.com/compute/v1/projects/project/zones/us-central1-c/instances/vm",
+			"vm",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetResourceNameFromURL(log.NewJSONLogger(os.Stdout), tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGetResourcePathFromURL(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return empty",
+			"https://someurl/",
+			"",
+		},
+		{
+			"Should return projects/project/zones/us-central1-c/disks/disk",
+			"https://www.googleapis.com/compute/v1/projects/project/zones/us-central1-c/disks/disk",
+			"projects/project/zones/us-central1-c/disks/disk",
+		},
+		{
+			"Should ignore the API version",
+			"https://compute.googleapis.com/compute/beta/projects/project/zones/us-central1-c/disks/disk",
+			"projects/project/zones/us-central1-c/disks/disk",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetResourcePathFromURL(log.NewJSONLogger(os.Stdout), tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGetGCPRegionFromZone(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return us-east1",
+			"us-east1-b",
+			"us-east1",
+		},
+		{
+			"Should return northamerica-northeast1",
+			"northamerica-northeast1-a",
+			"northamerica-northeast1",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetGCPRegionFromZone(tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGetGCPRegionFromLocation(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return the region of a zone",
+			"us-east1-b",
+			"us-east1",
+		},
+		{
+			"Should return the region itself",
+			"northamerica-northeast1",
+			"northamerica-northeast1",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetGCPRegionFromLocation(tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGetGCPZoneFromScope(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			"Should return us-east1-b",
+			"zones/us-east1-b",
+			"us-east1-b",
+		},
+		{
+			"Should return empty for regional scopes",
+			"regions/us-east1",
+			"",
+		},
+		{
+			"Should return empty for the global scope",
+			"global",
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		r := GetGCPZoneFromScope(tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %s got %s instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestSecondsSince(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		desc     string
+		input    string
+		expected float64
+		err      bool
+	}{
+		{"Should return 3600", "2023-04-01T11:00:00.000Z", 3600, false},
+		{"Should handle offsets", "2023-04-01T04:00:00.000-07:00", 3600, false},
+		{"Should fail on empty timestamps", "", 0, true},
+	}
+
+	for _, tc := range cases {
+		r, err := secondsSince(tc.input, now)
+		if (err != nil) != tc.err {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestAgeInDays(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		desc     string
+		input    string
+		expected float64
+		err      bool
+	}{
+		{"Should return 0 within the first day", "2023-04-01T00:00:00.000Z", 0, false},
+		{"Should round down to whole days", "2023-03-29T11:00:00.000-07:00", 2, false},
+		{"Should fail on empty timestamps", "", 0, true},
+	}
+
+	for _, tc := range cases {
+		r, err := ageInDays(tc.input, now)
+		if (err != nil) != tc.err {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestUnixTimestamp(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected float64
+		err      bool
+	}{
+		{"Should return 1680350400", "2023-04-01T12:00:00.000Z", 1680350400, false},
+		{"Should keep fractional seconds", "2023-04-01T05:00:00.500-07:00", 1680350400.5, false},
+		{"Should fail on empty timestamps", "", 0, true},
+	}
+
+	for _, tc := range cases {
+		r, err := unixTimestamp(tc.input)
+		if (err != nil) != tc.err {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+// collectFunc lets a collector's reporting logic be gathered by a registry.
+type collectFunc func(ch chan<- prometheus.Metric)
+
+func (f collectFunc) Describe(ch chan<- *prometheus.Desc) {}
+
+func (f collectFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+// gatherGauges returns the values of the gauges sent by collect, keyed by
+// "<metric>/<name label>". Duplicate series fail the test.
+func gatherGauges(t *testing.T, collect func(ch chan<- prometheus.Metric)) map[string]float64 {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collectFunc(collect))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gauges := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			var name string
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" {
+					name = l.GetValue()
+				}
+			}
+			gauges[family.GetName()+"/"+name] = m.GetGauge().GetValue()
+		}
+	}
+	return gauges
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,15 +14,41 @@ import (
 	"google.golang.org/api/option"
 )
 
+// dataprocJobsPageSize is the largest page of jobs the Dataproc API returns.
+const dataprocJobsPageSize = 100
+
 var (
-	isDataprocClusterRunning = prometheus.NewDesc("dataproc_is_cluster_running", "tells whether the Dataproc cluster is running", []string{"project", "region", "zone", "name"}, nil)
+	dataprocMaxJobPages = kingpin.Flag(
+		"collector.dataproc_is_cluster_running.max-job-pages",
+		"How many pages of the latest jobs of each region are looked for the last job submitted to clusters.",
+	).Default("5").Int()
+
+	isDataprocClusterRunning            = prometheus.NewDesc("dataproc_is_cluster_running", "tells whether the Dataproc cluster is running", []string{"project", "region", "zone", "name"}, nil)
+	dataprocClusterLastJobSeconds       = prometheus.NewDesc("dataproc_cluster_last_job_submission_seconds", "tells how many seconds ago the last job was submitted to the Dataproc cluster, or it was created when it ran none of the jobs listed, bounded by the oldest job listed when older ones weren't", []string{"project", "region", "zone", "name"}, nil)
+	dataprocClusterIdleDeleteConfigured = prometheus.NewDesc("dataproc_cluster_idle_delete_configured", "tells whether the Dataproc cluster is deleted after being idle for a while", []string{"project", "region", "zone", "name"}, nil)
+	dataprocClusterAutoDeleteConfigured = prometheus.NewDesc("dataproc_cluster_auto_delete_configured", "tells whether the Dataproc cluster is deleted at a given time", []string{"project", "region", "zone", "name"}, nil)
+	dataprocClusterWorkers              = prometheus.NewDesc("dataproc_cluster_workers", "tells how many workers the Dataproc cluster has", []string{"project", "region", "zone", "name", "role", "machine_type"}, nil)
 )
 
+// dataprocJobSubmissions tells when the last job was submitted to each cluster
+// of a region, by cluster UUID, among the latest jobs listed.
+type dataprocJobSubmissions struct {
+	last map[string]time.Time
+	// truncated tells whether older jobs were left out of the listing, the
+	// oldest listed one being submitted at oldest.
+	truncated bool
+	oldest    time.Time
+}
+
 type DataprocIsClusterRunningCollector struct {
 	logger           log.Logger
 	service          *dataproc.Service
 	project          string
 	monitoredRegions []string
+	maxJobPages      int
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
 	mutex            sync.RWMutex
 }
 
@@ -29,10 +57,15 @@ func init() {
 }
 
 func (e *DataprocIsClusterRunningCollector) ListMetrics() []string {
-	return []string{"dataproc_is_cluster_running"}
+	return []string{"dataproc_is_cluster_running", "dataproc_cluster_last_job_submission_seconds", "dataproc_cluster_idle_delete_configured", "dataproc_cluster_auto_delete_configured", "dataproc_cluster_workers", "dataproc_cluster_labels"}
 }
 
 func NewDataprocIsClusterRunningCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("dataproc_is_cluster_running", project)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 	gcpClient, err := NewGCPClient(ctx, dataproc.CloudPlatformScope)
 	if err != nil {
@@ -49,6 +82,10 @@ func NewDataprocIsClusterRunningCollector(logger log.Logger, project string, mon
 		service:          dataprocService,
 		project:          project,
 		monitoredRegions: monitoredRegions,
+		maxJobPages:      *dataprocMaxJobPages,
+		pagesFetched:     apiPagesFetched.WithLabelValues("dataproc_is_cluster_running", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("dataproc_cluster_labels", "GCP labels of the Dataproc cluster", []string{"project", "region", "zone", "name"}),
 	}, nil
 }
 
@@ -61,14 +98,30 @@ func (e *DataprocIsClusterRunningCollector) Update(ch chan<- prometheus.Metric)
 
 	for _, region := range e.monitoredRegions {
 		go func(ch chan<- prometheus.Metric, region string) {
-			regionalDataprocClusters, err := e.service.Projects.Regions.Clusters.List(e.project, region).Do()
+			regionalDataprocClusters := []*dataproc.Cluster{}
+			err := e.service.Projects.Regions.Clusters.List(e.project, region).Pages(context.Background(), func(page *dataproc.ListClustersResponse) error {
+				e.pagesFetched.Inc()
+				regionalDataprocClusters = append(regionalDataprocClusters, page.Clusters...)
+				return nil
+			})
 			if err != nil {
 				level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying Dataproc Clusters in %s at %s", e.project, region), "err", err)
 				wgRegions.Done()
 				return
 			}
 
-			for _, cluster := range regionalDataprocClusters.Clusters {
+			// Last job submissions are unknown, thus not reported, when jobs can't be listed.
+			lastJobSubmissions, err := e.listLastJobSubmissions(region)
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying Dataproc Jobs in %s at %s", e.project, region), "err", err)
+			}
+
+			now := time.Now()
+			for _, cluster := range regionalDataprocClusters {
+				if e.excluder.Excluded(cluster.ClusterName, cluster.Labels) {
+					continue
+				}
+
 				zone := GetGCPZoneFromURL(e.logger, cluster.Config.GceClusterConfig.ZoneUri)
 				if zone == "" {
 					// In case of GKE Dataproc clusters which have no Zone info
@@ -76,6 +129,8 @@ func (e *DataprocIsClusterRunningCollector) Update(ch chan<- prometheus.Metric)
 					zone = region
 				}
 
+				e.labelsInfo.Collect(ch, cluster.Labels, e.project, region, zone, cluster.ClusterName)
+
 				if cluster.Status.State == "RUNNING" {
 					ch <- prometheus.MustNewConstMetric(
 						isDataprocClusterRunning,
@@ -95,6 +150,19 @@ func (e *DataprocIsClusterRunningCollector) Update(ch chan<- prometheus.Metric)
 						zone,
 						cluster.ClusterName)
 				}
+
+				if submitted, ok := lastJobSubmission(lastJobSubmissions, cluster); ok {
+					ch <- prometheus.MustNewConstMetric(
+						dataprocClusterLastJobSeconds,
+						prometheus.GaugeValue,
+						now.Sub(submitted).Seconds(),
+						e.project,
+						region,
+						zone,
+						cluster.ClusterName)
+				}
+
+				e.reportConfig(ch, cluster, region, zone)
 			}
 
 			wgRegions.Done()
@@ -104,3 +172,136 @@ func (e *DataprocIsClusterRunningCollector) Update(ch chan<- prometheus.Metric)
 	wgRegions.Wait()
 	return nil
 }
+
+// reportConfig tells whether the cluster deletes itself and how many workers it runs.
+func (e *DataprocIsClusterRunningCollector) reportConfig(ch chan<- prometheus.Metric, cluster *dataproc.Cluster, region string, zone string) {
+	var idleDelete, autoDelete float64
+	if lc := cluster.Config.LifecycleConfig; lc != nil {
+		if lc.IdleDeleteTtl != "" {
+			idleDelete = 1.
+		}
+		if lc.AutoDeleteTime != "" || lc.AutoDeleteTtl != "" {
+			autoDelete = 1.
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(dataprocClusterIdleDeleteConfigured, prometheus.GaugeValue, idleDelete, e.project, region, zone, cluster.ClusterName)
+	ch <- prometheus.MustNewConstMetric(dataprocClusterAutoDeleteConfigured, prometheus.GaugeValue, autoDelete, e.project, region, zone, cluster.ClusterName)
+
+	for role, config := range map[string]*dataproc.InstanceGroupConfig{
+		"worker":           cluster.Config.WorkerConfig,
+		"secondary_worker": cluster.Config.SecondaryWorkerConfig,
+	} {
+		if config == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			dataprocClusterWorkers,
+			prometheus.GaugeValue,
+			float64(config.NumInstances),
+			e.project,
+			region,
+			zone,
+			cluster.ClusterName,
+			role,
+			GetResourceNameFromURL(e.logger, config.MachineTypeUri))
+	}
+}
+
+// listLastJobSubmissions returns when the last job was submitted to each
+// cluster of a region. Jobs are listed newest first, so only the latest pages
+// are looked at rather than the whole history of the region.
+func (e *DataprocIsClusterRunningCollector) listLastJobSubmissions(region string) (*dataprocJobSubmissions, error) {
+	submissions := &dataprocJobSubmissions{last: map[string]time.Time{}}
+	call := e.service.Projects.Regions.Jobs.List(e.project, region).PageSize(dataprocJobsPageSize)
+	for i := 0; i < e.maxJobPages; i++ {
+		page, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		e.pagesFetched.Inc()
+
+		for _, job := range page.Jobs {
+			submitted, ok := jobSubmissionTime(job)
+			if !ok {
+				continue
+			}
+			if submissions.oldest.IsZero() || submitted.Before(submissions.oldest) {
+				submissions.oldest = submitted
+			}
+
+			if job.Placement == nil {
+				continue
+			}
+			if last, seen := submissions.last[job.Placement.ClusterUuid]; !seen || submitted.After(last) {
+				submissions.last[job.Placement.ClusterUuid] = submitted
+			}
+		}
+
+		if page.NextPageToken == "" {
+			return submissions, nil
+		}
+		call.PageToken(page.NextPageToken)
+	}
+
+	submissions.truncated = true
+	return submissions, nil
+}
+
+// lastJobSubmission returns when the last job was submitted to a cluster,
+// falling back to its creation when no job was. Clusters created before the
+// oldest job listed may have run older jobs, which only bound how long ago
+// their last job was submitted. Nothing is returned when jobs couldn't be listed.
+func lastJobSubmission(submissions *dataprocJobSubmissions, cluster *dataproc.Cluster) (time.Time, bool) {
+	if submissions == nil {
+		return time.Time{}, false
+	}
+	if submitted, ok := submissions.last[cluster.ClusterUuid]; ok {
+		return submitted, true
+	}
+
+	created, ok := clusterCreationTime(cluster)
+	if submissions.truncated && (!ok || submissions.oldest.IsZero() || created.Before(submissions.oldest)) {
+		return submissions.oldest, !submissions.oldest.IsZero()
+	}
+	return created, ok
+}
+
+// jobSubmissionTime returns when a job entered its first state, i.e. when it was submitted.
+func jobSubmissionTime(job *dataproc.Job) (time.Time, bool) {
+	startTimes := []string{}
+	for _, status := range append([]*dataproc.JobStatus{job.Status}, job.StatusHistory...) {
+		if status != nil {
+			startTimes = append(startTimes, status.StateStartTime)
+		}
+	}
+	return earliestTime(startTimes)
+}
+
+// clusterCreationTime returns when a cluster entered its first state, i.e. when it was created.
+func clusterCreationTime(cluster *dataproc.Cluster) (time.Time, bool) {
+	startTimes := []string{}
+	for _, status := range append([]*dataproc.ClusterStatus{cluster.Status}, cluster.StatusHistory...) {
+		if status != nil {
+			startTimes = append(startTimes, status.StateStartTime)
+		}
+	}
+	return earliestTime(startTimes)
+}
+
+// earliestTime returns the earliest of RFC 3339 timestamps, skipping invalid ones.
+func earliestTime(timestamps []string) (time.Time, bool) {
+	var earliest time.Time
+	for _, timestamp := range timestamps {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			continue
+		}
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+
+	return earliest, !earliest.IsZero()
+}
@@ -1,8 +1,16 @@
 package collector
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/dataproc/v1"
+	"google.golang.org/api/option"
 )
 
 func TestDataprocIsClusterRunningCollectorListMetrics(t *testing.T) {
@@ -10,7 +18,7 @@ func TestDataprocIsClusterRunningCollectorListMetrics(t *testing.T) {
 		desc     string
 		expected []string
 	}{
-		{"should list available metrics for dataproc_is_cluster_running collector", []string{"dataproc_is_cluster_running"}},
+		{"should list available metrics for dataproc_is_cluster_running collector", []string{"dataproc_is_cluster_running", "dataproc_cluster_last_job_submission_seconds", "dataproc_cluster_idle_delete_configured", "dataproc_cluster_auto_delete_configured", "dataproc_cluster_workers", "dataproc_cluster_labels"}},
 	}
 
 	for _, tc := range cases {
@@ -20,3 +28,220 @@ func TestDataprocIsClusterRunningCollectorListMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestJobSubmissionTime(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *dataproc.Job
+		expected time.Time
+		ok       bool
+	}{
+		{
+			"should have no submission time without status",
+			&dataproc.Job{},
+			time.Time{},
+			false,
+		},
+		{
+			"should return the start of the first state",
+			&dataproc.Job{
+				Status: &dataproc.JobStatus{State: "DONE", StateStartTime: "2023-04-01T13:00:00.123Z"},
+				StatusHistory: []*dataproc.JobStatus{
+					{State: "PENDING", StateStartTime: "2023-04-01T12:00:00.000Z"},
+					{State: "RUNNING", StateStartTime: "2023-04-01T12:00:05.000Z"},
+				},
+			},
+			time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		r, ok := jobSubmissionTime(tc.input)
+		if !r.Equal(tc.expected) || ok != tc.ok {
+			t.Errorf("%s want %s %v got %s %v instead", tc.desc, tc.expected, tc.ok, r, ok)
+		}
+	}
+}
+
+func TestClusterCreationTime(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *dataproc.Cluster
+		expected time.Time
+		ok       bool
+	}{
+		{
+			"should have no creation time without status",
+			&dataproc.Cluster{},
+			time.Time{},
+			false,
+		},
+		{
+			"should return the start of the first state",
+			&dataproc.Cluster{
+				Status: &dataproc.ClusterStatus{State: "RUNNING", StateStartTime: "2023-04-01T12:05:00.000Z"},
+				StatusHistory: []*dataproc.ClusterStatus{
+					{State: "CREATING", StateStartTime: "2023-04-01T12:00:00.000Z"},
+				},
+			},
+			time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		r, ok := clusterCreationTime(tc.input)
+		if !r.Equal(tc.expected) || ok != tc.ok {
+			t.Errorf("%s want %s %v got %s %v instead", tc.desc, tc.expected, tc.ok, r, ok)
+		}
+	}
+}
+
+func TestListLastJobSubmissions(t *testing.T) {
+	// Pages are served newest first, the old cluster's job being past the page cap.
+	pages := map[string]string{
+		"": `{"jobs": [
+			{"placement": {"clusterUuid": "uuid"}, "status": {"stateStartTime": "2023-04-03T12:00:00Z"}}
+		], "nextPageToken": "2"}`,
+		"2": `{"jobs": [
+			{"placement": {"clusterUuid": "uuid"}, "status": {"stateStartTime": "2023-04-02T12:00:00Z"}}
+		], "nextPageToken": "3"}`,
+		"3": `{"jobs": [
+			{"placement": {"clusterUuid": "old-uuid"}, "status": {"stateStartTime": "2023-04-01T12:00:00Z"}}
+		]}`,
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, ok := pages[r.URL.Query().Get("pageToken")]
+		if r.URL.Path != "/v1/projects/project/regions/us-east1/jobs" || r.URL.Query().Get("pageSize") != "100" || !ok {
+			http.Error(w, "unexpected query "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	service, err := dataproc.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc        string
+		maxJobPages int
+		pages       int
+		expected    *dataprocJobSubmissions
+	}{
+		{
+			"should list every page below the cap",
+			3,
+			3,
+			&dataprocJobSubmissions{
+				last: map[string]time.Time{
+					"uuid":     time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC),
+					"old-uuid": time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+				},
+				oldest: time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			"should tell the listing was truncated at the cap",
+			2,
+			2,
+			&dataprocJobSubmissions{
+				last:      map[string]time.Time{"uuid": time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC)},
+				truncated: true,
+				oldest:    time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		requests = 0
+		collector := DataprocIsClusterRunningCollector{
+			service:      service,
+			project:      "project",
+			maxJobPages:  tc.maxJobPages,
+			pagesFetched: prometheus.NewCounter(prometheus.CounterOpts{Name: "pages"}),
+		}
+		r, err := collector.listLastJobSubmissions("us-east1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if requests != tc.pages || !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %d pages %+v got %d pages %+v instead", tc.desc, tc.pages, tc.expected, requests, r)
+		}
+	}
+}
+
+func TestLastJobSubmission(t *testing.T) {
+	createdAt := func(timestamp string) *dataproc.Cluster {
+		return &dataproc.Cluster{ClusterUuid: "old-uuid", Status: &dataproc.ClusterStatus{StateStartTime: timestamp}}
+	}
+	truncated := &dataprocJobSubmissions{
+		last:      map[string]time.Time{"uuid": time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC)},
+		truncated: true,
+		oldest:    time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC),
+	}
+	complete := &dataprocJobSubmissions{
+		last:   truncated.last,
+		oldest: truncated.oldest,
+	}
+
+	cases := []struct {
+		desc        string
+		submissions *dataprocJobSubmissions
+		cluster     *dataproc.Cluster
+		expected    time.Time
+		ok          bool
+	}{
+		{
+			"should be unknown when jobs couldn't be listed",
+			nil,
+			createdAt("2023-03-01T12:00:00Z"),
+			time.Time{},
+			false,
+		},
+		{
+			"should return the last job submitted to the cluster",
+			truncated,
+			&dataproc.Cluster{ClusterUuid: "uuid"},
+			time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC),
+			true,
+		},
+		{
+			"should fall back to the creation of clusters which never ran a job",
+			complete,
+			createdAt("2023-03-01T12:00:00Z"),
+			time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC),
+			true,
+		},
+		{
+			"should bound by the oldest job listed when older jobs weren't",
+			truncated,
+			createdAt("2023-03-01T12:00:00Z"),
+			time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC),
+			true,
+		},
+		{
+			"should fall back to the creation of clusters created after the oldest job listed",
+			truncated,
+			createdAt("2023-04-02T18:00:00Z"),
+			time.Date(2023, 4, 2, 18, 0, 0, 0, time.UTC),
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		r, ok := lastJobSubmission(tc.submissions, tc.cluster)
+		if !r.Equal(tc.expected) || ok != tc.ok {
+			t.Errorf("%s want %s %v got %s %v instead", tc.desc, tc.expected, tc.ok, r, ok)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	excludeNameRegex = kingpin.Flag(
+		"exclude.name-regex",
+		"Resources whose name fully matches this regex are not reported by any collector.",
+	).Default("").String()
+
+	excludeLabels = kingpin.Flag(
+		"exclude.labels",
+		"Comma-separated GCP label selectors, key=value or key, whose matching resources are not reported by any collector. e.g: purpose=dr-standby,keep",
+	).Default("").String()
+
+	excludedResources = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gcp",
+			Subsystem: "scrape",
+			Name:      "excluded_resources_total",
+			Help:      "gcp_idleness_exporter: Number of resources skipped by a collector because of an exclusion.",
+		},
+		[]string{"collector", "project"},
+	)
+
+	collectorExcludeNameRegex = make(map[string]*string)
+	collectorExcludeLabels    = make(map[string]*string)
+)
+
+// registerExclusionFlags adds the flags excluding resources from a single collector.
+func registerExclusionFlags(collector string) {
+	collectorExcludeNameRegex[collector] = kingpin.Flag(
+		fmt.Sprintf("collector.%s.exclude.name-regex", collector),
+		fmt.Sprintf("Resources whose name fully matches this regex are not reported by the %s collector.", collector),
+	).Default("").String()
+
+	collectorExcludeLabels[collector] = kingpin.Flag(
+		fmt.Sprintf("collector.%s.exclude.labels", collector),
+		fmt.Sprintf("Comma-separated GCP label selectors, key=value or key, whose matching resources are not reported by the %s collector.", collector),
+	).Default("").String()
+}
+
+type labelSelector struct {
+	key   string
+	value string
+	// anyValue matches resources having the key regardless of its value.
+	anyValue bool
+}
+
+func (s labelSelector) matches(labels map[string]string) bool {
+	v, ok := labels[s.key]
+	return ok && (s.anyValue || v == s.value)
+}
+
+// ResourceExcluder tells which resources a collector must skip, based on the
+// global and the collector's own exclusions.
+type ResourceExcluder struct {
+	nameRegexes    []*regexp.Regexp
+	labelSelectors []labelSelector
+	excluded       prometheus.Counter
+}
+
+// NewResourceExcluder creates the ResourceExcluder of a collector for a project.
+func NewResourceExcluder(collector string, project string) (*ResourceExcluder, error) {
+	x, err := compileExclusions(collector)
+	if err != nil {
+		return nil, err
+	}
+
+	x.excluded = excludedResources.WithLabelValues(collector, project)
+	return x, nil
+}
+
+// ValidateExclusions checks the global and every collector's exclusions.
+func ValidateExclusions() error {
+	for collector := range collectorExcludeNameRegex {
+		if _, err := compileExclusions(collector); err != nil {
+			return fmt.Errorf("collector %s: %w", collector, err)
+		}
+	}
+	return nil
+}
+
+func compileExclusions(collector string) (*ResourceExcluder, error) {
+	x := &ResourceExcluder{}
+
+	nameRegexes := []string{*excludeNameRegex}
+	selectors := []string{*excludeLabels}
+	if f, ok := collectorExcludeNameRegex[collector]; ok {
+		nameRegexes = append(nameRegexes, *f)
+	}
+	if f, ok := collectorExcludeLabels[collector]; ok {
+		selectors = append(selectors, *f)
+	}
+
+	for _, r := range nameRegexes {
+		if r == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + r + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion name regex %q: %w", r, err)
+		}
+		x.nameRegexes = append(x.nameRegexes, re)
+	}
+
+	for _, s := range selectors {
+		parsed, err := parseLabelSelectors(s)
+		if err != nil {
+			return nil, err
+		}
+		x.labelSelectors = append(x.labelSelectors, parsed...)
+	}
+
+	return x, nil
+}
+
+// Excluded tells whether a resource must not be reported and counts it if so.
+func (x *ResourceExcluder) Excluded(name string, labels map[string]string) bool {
+	if x == nil {
+		return false
+	}
+
+	for _, re := range x.nameRegexes {
+		if re.MatchString(name) {
+			x.excluded.Inc()
+			return true
+		}
+	}
+	for _, s := range x.labelSelectors {
+		if s.matches(labels) {
+			x.excluded.Inc()
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseLabelSelectors parses comma-separated key=value or key label selectors.
+func parseLabelSelectors(s string) ([]labelSelector, error) {
+	selectors := []labelSelector{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid exclusion label selector %q", item)
+		}
+		selectors = append(selectors, labelSelector{key: key, value: strings.TrimSpace(value), anyValue: !hasValue})
+	}
+	return selectors, nil
+}
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseLabelSelectors(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected []labelSelector
+		err      bool
+	}{
+		{"should return no selectors", "", []labelSelector{}, false},
+		{
+			"should parse key=value and key selectors",
+			"purpose=dr-standby, keep",
+			[]labelSelector{{key: "purpose", value: "dr-standby"}, {key: "keep", anyValue: true}},
+			false,
+		},
+		{"should fail without key", "=value", nil, true},
+	}
+
+	for _, tc := range cases {
+		r, err := parseLabelSelectors(tc.input)
+		if (err != nil) != tc.err {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %+v got %+v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestResourceExcluderExcluded(t *testing.T) {
+	x := &ResourceExcluder{
+		nameRegexes:    []*regexp.Regexp{regexp.MustCompile("^(?:dr-.*)$")},
+		labelSelectors: []labelSelector{{key: "purpose", value: "standby"}, {key: "keep", anyValue: true}},
+		excluded:       prometheus.NewCounter(prometheus.CounterOpts{Name: "test_excluded_total"}),
+	}
+
+	cases := []struct {
+		desc     string
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{"should exclude by name", "dr-db", nil, true},
+		{"should exclude by label value", "db", map[string]string{"purpose": "standby"}, true},
+		{"should keep other label values", "db", map[string]string{"purpose": "batch"}, false},
+		{"should exclude by label presence", "db", map[string]string{"keep": ""}, true},
+		{"should keep names matching partially", "db-dr-db", nil, false},
+	}
+
+	for _, tc := range cases {
+		if r := x.Excluded(tc.name, tc.labels); r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	isAddressInUse = prometheus.NewDesc("gce_address_in_use", "tells whether the static IP address is in use by some resource", []string{"project", "region", "name", "status", "address_type", "network_tier", "user"}, nil)
+)
+
+type GCEAddressInUseCollector struct {
+	logger           log.Logger
+	service          *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_address_in_use", defaultEnabled, NewGCEAddressInUseCollector)
+}
+
+func (e *GCEAddressInUseCollector) ListMetrics() []string {
+	return []string{"gce_address_in_use", "gcp_idle_resource_estimated_monthly_cost"}
+}
+
+func NewGCEAddressInUseCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_address_in_use", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEAddressInUseCollector{
+		logger:           logger,
+		service:          computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_address_in_use", project),
+		excluder:         excluder,
+	}, nil
+}
+
+func (e *GCEAddressInUseCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	var wgRegions sync.WaitGroup
+	wgRegions.Add(len(e.monitoredRegions))
+
+	var errsMutex sync.Mutex
+	errs := []error{}
+
+	for _, region := range e.monitoredRegions {
+		go func(ch chan<- prometheus.Metric, region string) {
+			defer wgRegions.Done()
+
+			err := e.service.Addresses.List(e.project, region).Pages(ctx, func(page *compute.AddressList) error {
+				e.pagesFetched.Inc()
+				for _, address := range page.Items {
+					e.report(ch, address, region)
+				}
+				return nil
+			})
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting addresses for project %s in region %s", e.project, region), "err", err)
+				errsMutex.Lock()
+				errs = append(errs, err)
+				errsMutex.Unlock()
+			}
+		}(ch, region)
+	}
+
+	err := e.service.GlobalAddresses.List(e.project).Pages(ctx, func(page *compute.AddressList) error {
+		e.pagesFetched.Inc()
+		for _, address := range page.Items {
+			e.report(ch, address, "global")
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting global addresses for project %s", e.project), "err", err)
+		errsMutex.Lock()
+		errs = append(errs, err)
+		errsMutex.Unlock()
+	}
+
+	wgRegions.Wait()
+	return errors.Join(errs...)
+}
+
+func (e *GCEAddressInUseCollector) report(ch chan<- prometheus.Metric, address *compute.Address, region string) {
+	// Addresses carry no labels in the compute v1 API.
+	if e.excluder.Excluded(address.Name, nil) {
+		return
+	}
+
+	var inUse float64
+	if address.Status == "IN_USE" {
+		inUse = 1.0
+	}
+
+	users := []string{}
+	for _, u := range address.Users {
+		users = append(users, GetResourceNameFromURL(e.logger, u))
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		isAddressInUse,
+		prometheus.GaugeValue,
+		inUse,
+		e.project,
+		region,
+		address.Name,
+		address.Status,
+		address.AddressType,
+		address.NetworkTier,
+		strings.Join(users, ","))
+
+	// Only external addresses are billed when they aren't in use.
+	if address.Status == "RESERVED" && address.AddressType != "INTERNAL" {
+		collectIdleResourceCost(ch, e.logger, func(p pricing.Pricer) (float64, error) {
+			return p.StaticIPMonth(region)
+		}, e.project, region, "address", address.Name)
+	}
+}
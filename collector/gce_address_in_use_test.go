@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+func TestGCEAddressInUseCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_address_in_use collector", []string{"gce_address_in_use", "gcp_idle_resource_estimated_monthly_cost"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEAddressInUseCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestGCEAddressInUseCollectorUpdate(t *testing.T) {
+	responses := map[string]string{
+		"/projects/project/regions/us-east1/addresses": `{"items": [
+			{"name": "in-use", "status": "IN_USE", "addressType": "EXTERNAL", "users": ["https://www.googleapis.com/compute/v1/projects/project/zones/us-east1-b/instances/vm"]},
+			{"name": "reserved-external", "status": "RESERVED", "addressType": "EXTERNAL"},
+			{"name": "reserved-internal", "status": "RESERVED", "addressType": "INTERNAL"}
+		]}`,
+		"/projects/project/regions/europe-west1/addresses": `{"items": [
+			{"name": "reserved-europe", "status": "RESERVED", "addressType": "EXTERNAL"}
+		]}`,
+		"/projects/project/global/addresses": `{"items": [
+			{"name": "reserved-global", "status": "RESERVED", "addressType": "EXTERNAL"}
+		]}`,
+	}
+
+	var mutex sync.Mutex
+	requested := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requested = append(requested, r.URL.Path)
+		mutex.Unlock()
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, "unexpected query "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	service, err := compute.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(p pricing.Pricer) { Pricer = p }(Pricer)
+	Pricer = fakePricer{}
+
+	collector := GCEAddressInUseCollector{
+		logger:           log.NewJSONLogger(os.Stdout),
+		service:          service,
+		project:          "project",
+		monitoredRegions: []string{"us-east1"},
+		pagesFetched:     prometheus.NewCounter(prometheus.CounterOpts{Name: "pages"}),
+	}
+	r := gatherGauges(t, func(ch chan<- prometheus.Metric) {
+		if err := collector.Update(ch); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Addresses of regions which aren't monitored are never requested.
+	sort.Strings(requested)
+	expectedRequests := []string{"/projects/project/global/addresses", "/projects/project/regions/us-east1/addresses"}
+	if !reflect.DeepEqual(requested, expectedRequests) {
+		t.Errorf("should only request the monitored regions want %v got %v instead", expectedRequests, requested)
+	}
+
+	expected := map[string]float64{
+		"gce_address_in_use/in-use":                                  1,
+		"gce_address_in_use/reserved-external":                       0,
+		"gce_address_in_use/reserved-internal":                       0,
+		"gce_address_in_use/reserved-global":                         0,
+		"gcp_idle_resource_estimated_monthly_cost/reserved-external": 7.3,
+		"gcp_idle_resource_estimated_monthly_cost/reserved-global":   7.3,
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("should report addresses in use and the cost of reserved external ones want %v got %v instead", expected, r)
+	}
+
+	// Addresses of a monitored region which can't be listed fail the update.
+	collector.monitoredRegions = []string{"us-east1", "asia-east1"}
+	ch := make(chan prometheus.Metric, 100)
+	if err := collector.Update(ch); err == nil {
+		t.Errorf("should fail when addresses of a region can't be listed")
+	}
+}
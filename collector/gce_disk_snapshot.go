@@ -3,10 +3,10 @@ package collector
 import (
 	"context"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 
+	"github.com/7onn/gcp-idleness-exporter/pricing"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -16,8 +16,10 @@ import (
 )
 
 var (
-	metricDiskSnapshotAge    = prometheus.NewDesc("gce_disk_snapshot_age_days", "tells how many days the snapshot has", []string{"project", "disk", "snapshot"}, nil)
-	metricDiskSnapshotAmount = prometheus.NewDesc("gce_disk_snapshot_amount", "tells how many snapshots the Disk has", []string{"project", "disk"}, nil)
+	metricDiskSnapshotAge          = prometheus.NewDesc("gce_disk_snapshot_age_days", "tells how many days the snapshot has", []string{"project", "disk", "snapshot"}, nil)
+	metricDiskSnapshotAmount       = prometheus.NewDesc("gce_disk_snapshot_amount", "tells how many snapshots the Disk has", []string{"project", "disk"}, nil)
+	metricDiskSnapshotOrphaned     = prometheus.NewDesc("gce_disk_snapshot_is_orphaned", "tells whether the snapshot's source disk no longer exists", []string{"project", "disk", "snapshot"}, nil)
+	metricDiskSnapshotStorageBytes = prometheus.NewDesc("gce_disk_snapshot_storage_bytes", "tells how many bytes of storage the snapshot uses", []string{"project", "disk", "snapshot"}, nil)
 )
 
 func init() {
@@ -25,7 +27,7 @@ func init() {
 }
 
 func (e *GCEDiskSnapshotCollector) ListMetrics() []string {
-	return []string{"gce_disk_snapshot_age_days", "gce_disk_snapshot_amount"}
+	return []string{"gce_disk_snapshot_age_days", "gce_disk_snapshot_amount", "gce_disk_snapshot_is_orphaned", "gce_disk_snapshot_storage_bytes", "gce_disk_snapshot_labels", "gcp_idle_resource_estimated_monthly_cost"}
 }
 
 type GCEDiskSnapshotCollector struct {
@@ -34,10 +36,18 @@ type GCEDiskSnapshotCollector struct {
 	project          string
 	monitoredRegions []string
 	metrics          []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
 	mutex            sync.RWMutex
 }
 
 func NewGCEDiskSnapshotCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_disk_snapshot", project)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
 	if err != nil {
@@ -54,7 +64,10 @@ func NewGCEDiskSnapshotCollector(logger log.Logger, project string, monitoredReg
 		service:          computeService,
 		project:          project,
 		monitoredRegions: monitoredRegions,
-		metrics:          []string{"gce_disk_snapshot_amount", "gce_disk_snapshot_age_days"},
+		metrics:          []string{"gce_disk_snapshot_amount", "gce_disk_snapshot_age_days", "gce_disk_snapshot_is_orphaned", "gce_disk_snapshot_storage_bytes", "gce_disk_snapshot_labels", "gcp_idle_resource_estimated_monthly_cost"},
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_disk_snapshot", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gce_disk_snapshot_labels", "GCP labels of the snapshot", []string{"project", "disk", "snapshot"}),
 	}, nil
 }
 
@@ -62,22 +75,73 @@ func (e *GCEDiskSnapshotCollector) Update(ch chan<- prometheus.Metric) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	snapshots, err := e.service.Snapshots.List(e.project).Do()
+	snapshots := []*compute.Snapshot{}
+	err := e.service.Snapshots.List(e.project).Pages(context.Background(), func(page *compute.SnapshotList) error {
+		e.pagesFetched.Inc()
+		snapshots = append(snapshots, page.Items...)
+		return nil
+	})
 	if err != nil {
 		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting disk snapshots for project %s", e.project), "err", err)
 		return err
 	}
 
+	liveDisks, err := e.listLiveDisks()
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting disks for project %s, orphaned snapshots won't be reported", e.project), "err", err)
+	}
+
 	diskSnapshotAmount := map[string]int{}
 	reportedSnapshots := []string{}
-	for _, snapshot := range snapshots.Items {
+	for _, snapshot := range snapshots {
 		if lo.Contains(reportedSnapshots, snapshot.Name) {
 			continue
 		}
 		reportedSnapshots = append(reportedSnapshots, snapshot.Name)
+		if e.excluder.Excluded(snapshot.Name, snapshot.Labels) {
+			continue
+		}
+
 		diskSnapshotAmount[GetDiskNameFromURL(e.logger, snapshot.SourceDisk)]++
 
-		snapshotCreationTimestamp, err := time.Parse(time.RFC3339, snapshot.CreationTimestamp)
+		ch <- prometheus.MustNewConstMetric(
+			metricDiskSnapshotStorageBytes,
+			prometheus.GaugeValue,
+			float64(snapshot.StorageBytes),
+			e.project,
+			GetDiskNameFromURL(e.logger, snapshot.SourceDisk),
+			snapshot.Name)
+
+		e.labelsInfo.Collect(ch, snapshot.Labels, e.project, GetDiskNameFromURL(e.logger, snapshot.SourceDisk), snapshot.Name)
+
+		if orphaned, ok := snapshotOrphaned(e.logger, snapshot, liveDisks); ok {
+			var isOrphaned float64
+			if orphaned {
+				isOrphaned = 1.0
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				metricDiskSnapshotOrphaned,
+				prometheus.GaugeValue,
+				isOrphaned,
+				e.project,
+				GetDiskNameFromURL(e.logger, snapshot.SourceDisk),
+				snapshot.Name)
+
+			if orphaned {
+				location := "global"
+				if len(snapshot.StorageLocations) > 0 {
+					location = snapshot.StorageLocations[0]
+				}
+
+				collectIdleResourceCost(ch, e.logger, func(p pricing.Pricer) (float64, error) {
+					price, err := p.SnapshotGBMonth(location)
+					return price * float64(snapshot.StorageBytes) / bytesPerGB, err
+				}, e.project, location, "snapshot", snapshot.Name)
+			}
+		}
+
+		age, err := ageInDays(snapshot.CreationTimestamp, time.Now())
 		if err != nil {
 			level.Error(e.logger).Log("msg", fmt.Sprintf("error parsing %s snapshot's CreationTimestamp for project %s", snapshot.Name, e.project), "err", err)
 			continue
@@ -86,7 +150,7 @@ func (e *GCEDiskSnapshotCollector) Update(ch chan<- prometheus.Metric) error {
 		ch <- prometheus.MustNewConstMetric(
 			metricDiskSnapshotAge,
 			prometheus.GaugeValue,
-			math.Floor(time.Since(snapshotCreationTimestamp).Hours()/24),
+			age,
 			e.project,
 			GetDiskNameFromURL(e.logger, snapshot.SourceDisk),
 			snapshot.Name)
@@ -103,3 +167,32 @@ func (e *GCEDiskSnapshotCollector) Update(ch chan<- prometheus.Metric) error {
 
 	return nil
 }
+
+// snapshotOrphaned tells whether the source disk of a snapshot no longer
+// exists. Nothing is known when disks couldn't be listed or when the snapshot
+// doesn't tell its source disk.
+func snapshotOrphaned(logger log.Logger, snapshot *compute.Snapshot, liveDisks map[string]bool) (bool, bool) {
+	if liveDisks == nil || snapshot.SourceDisk == "" {
+		return false, false
+	}
+	return !liveDisks[GetResourcePathFromURL(logger, snapshot.SourceDisk)], true
+}
+
+// listLiveDisks returns the resource paths of every zonal and regional disk in the project.
+func (e *GCEDiskSnapshotCollector) listLiveDisks() (map[string]bool, error) {
+	disks := map[string]bool{}
+	err := e.service.Disks.AggregatedList(e.project).Pages(context.Background(), func(page *compute.DiskAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			for _, disk := range scoped.Disks {
+				disks[GetResourcePathFromURL(e.logger, disk.SelfLink)] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return disks, nil
+}
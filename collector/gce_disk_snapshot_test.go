@@ -1,8 +1,12 @@
 package collector
 
 import (
+	"os"
 	"reflect"
 	"testing"
+
+	"github.com/go-kit/log"
+	"google.golang.org/api/compute/v1"
 )
 
 func TestGCEDiskSnapshotCollectorListMetrics(t *testing.T) {
@@ -10,7 +14,7 @@ func TestGCEDiskSnapshotCollectorListMetrics(t *testing.T) {
 		desc     string
 		expected []string
 	}{
-		{"should list available metrics for gce_disk_snapshot collector", []string{"gce_disk_snapshot_age_days", "gce_disk_snapshot_amount"}},
+		{"should list available metrics for gce_disk_snapshot collector", []string{"gce_disk_snapshot_age_days", "gce_disk_snapshot_amount", "gce_disk_snapshot_is_orphaned", "gce_disk_snapshot_storage_bytes", "gce_disk_snapshot_labels", "gcp_idle_resource_estimated_monthly_cost"}},
 	}
 
 	for _, tc := range cases {
@@ -20,3 +24,26 @@ func TestGCEDiskSnapshotCollectorListMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestSnapshotOrphaned(t *testing.T) {
+	liveDisks := map[string]bool{"projects/project/zones/us-east1-b/disks/live": true}
+	cases := []struct {
+		desc       string
+		sourceDisk string
+		liveDisks  map[string]bool
+		orphaned   bool
+		known      bool
+	}{
+		{"should not be orphaned while its source disk exists", "https://www.googleapis.com/compute/v1/projects/project/zones/us-east1-b/disks/live", liveDisks, false, true},
+		{"should be orphaned once its source disk is deleted", "https://www.googleapis.com/compute/v1/projects/project/zones/us-east1-b/disks/deleted", liveDisks, true, true},
+		{"should be unknown without source disk", "", liveDisks, false, false},
+		{"should be unknown when disks couldn't be listed", "https://www.googleapis.com/compute/v1/projects/project/zones/us-east1-b/disks/live", nil, false, false},
+	}
+
+	for _, tc := range cases {
+		orphaned, known := snapshotOrphaned(log.NewJSONLogger(os.Stdout), &compute.Snapshot{SourceDisk: tc.sourceDisk}, tc.liveDisks)
+		if orphaned != tc.orphaned || known != tc.known {
+			t.Errorf("%s want %v %v got %v %v instead", tc.desc, tc.orphaned, tc.known, orphaned, known)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	forwardingRuleHasBackends = prometheus.NewDesc("gce_forwarding_rule_has_backends", "tells whether any backend of the load balancer forwarding rule has instances or endpoints attached", []string{"project", "region", "name", "target_type"}, nil)
+	forwardingRuleBackends    = prometheus.NewDesc("gce_forwarding_rule_backends", "tells how many instances and endpoints the backends of the load balancer forwarding rule have", []string{"project", "region", "name", "target_type"}, nil)
+)
+
+type GCEForwardingRuleCollector struct {
+	logger           log.Logger
+	service          *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_forwarding_rule", defaultEnabled, NewGCEForwardingRuleCollector)
+}
+
+func (e *GCEForwardingRuleCollector) ListMetrics() []string {
+	return []string{"gce_forwarding_rule_has_backends", "gce_forwarding_rule_backends", "gce_forwarding_rule_labels"}
+}
+
+func NewGCEForwardingRuleCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_forwarding_rule", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEForwardingRuleCollector{
+		logger:           logger,
+		service:          computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_forwarding_rule", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gce_forwarding_rule_labels", "GCP labels of the forwarding rule", []string{"project", "region", "name"}),
+	}, nil
+}
+
+func (e *GCEForwardingRuleCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	rules := []*compute.ForwardingRule{}
+	err := e.service.ForwardingRules.AggregatedList(e.project).Pages(ctx, func(page *compute.ForwardingRuleAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			for _, rule := range scoped.ForwardingRules {
+				if lo.Contains(e.monitoredRegions, GetGCPRegionFromURL(e.logger, rule.Region)) {
+					rules = append(rules, rule)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting forwarding rules for project %s", e.project), "err", err)
+		return err
+	}
+
+	err = e.service.GlobalForwardingRules.List(e.project).Pages(ctx, func(page *compute.ForwardingRuleList) error {
+		e.pagesFetched.Inc()
+		rules = append(rules, page.Items...)
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting global forwarding rules for project %s", e.project), "err", err)
+		return err
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	graph, err := e.listLoadBalancerGraph(ctx, rules)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting load balancer resources for project %s", e.project), "err", err)
+		return err
+	}
+
+	for _, rule := range rules {
+		if e.excluder.Excluded(rule.Name, rule.Labels) {
+			continue
+		}
+
+		region := GetGCPRegionFromURL(e.logger, rule.Region)
+		if region == "" {
+			region = "global"
+		}
+
+		target := rule.Target
+		if target == "" {
+			target = rule.BackendService
+		}
+		targetPath := GetResourcePathFromURL(e.logger, target)
+
+		backends, ok := graph.backends(targetPath)
+		if !ok {
+			// e.g. Private Service Connect endpoints or VPN gateways.
+			continue
+		}
+
+		var hasBackends float64
+		if backends > 0 {
+			hasBackends = 1.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(forwardingRuleHasBackends, prometheus.GaugeValue, hasBackends, e.project, region, rule.Name, resourceCollection(targetPath))
+		ch <- prometheus.MustNewConstMetric(forwardingRuleBackends, prometheus.GaugeValue, float64(backends), e.project, region, rule.Name, resourceCollection(targetPath))
+		e.labelsInfo.Collect(ch, rule.Labels, e.project, region, rule.Name)
+	}
+
+	return nil
+}
+
+// loadBalancerGraph links load balancer resources, by resource path, from
+// target proxies down to the instance groups and network endpoint groups
+// serving them.
+type loadBalancerGraph struct {
+	// proxies points target proxies at their URL map or backend service.
+	proxies map[string]string
+	// urlMaps lists the backend services and buckets a URL map routes to.
+	urlMaps map[string][]string
+	// backendServices lists the instance groups and network endpoint groups of a backend service.
+	backendServices map[string][]string
+	// groupSizes tells how many instances or endpoints a group has.
+	groupSizes map[string]int64
+	// targetPools tells how many instances a target pool has.
+	targetPools map[string]int64
+	// targetInstances points target instances at their VM.
+	targetInstances map[string]string
+	// runningInstances tells which VMs are running.
+	runningInstances map[string]bool
+}
+
+// backends counts the instances and endpoints behind a forwarding rule's
+// target, telling whether the target is a load balancer resource at all.
+func (g *loadBalancerGraph) backends(path string) (int64, bool) {
+	switch resourceCollection(path) {
+	case "targetHttpProxies", "targetHttpsProxies", "targetGrpcProxies", "targetTcpProxies", "targetSslProxies":
+		next, ok := g.proxies[path]
+		if !ok {
+			return 0, false
+		}
+		return g.backends(next)
+	case "urlMaps":
+		services, ok := g.urlMaps[path]
+		if !ok {
+			return 0, false
+		}
+		var backends int64
+		for _, s := range services {
+			n, _ := g.backends(s)
+			backends += n
+		}
+		return backends, true
+	case "backendServices":
+		groups, ok := g.backendServices[path]
+		if !ok {
+			return 0, false
+		}
+		var backends int64
+		for _, group := range groups {
+			backends += g.groupSizes[group]
+		}
+		return backends, true
+	case "backendBuckets":
+		return 1, true
+	case "targetInstances":
+		instance, ok := g.targetInstances[path]
+		if !ok {
+			return 0, false
+		}
+		if g.runningInstances[instance] {
+			return 1, true
+		}
+		return 0, true
+	case "targetPools":
+		instances, ok := g.targetPools[path]
+		return instances, ok
+	default:
+		return 0, false
+	}
+}
+
+// resourceCollection returns the collection of a resource path, e.g. urlMaps.
+func resourceCollection(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[len(parts)-2]
+}
+
+// urlMapServices returns the backend services and buckets a URL map routes to.
+func urlMapServices(logger log.Logger, urlMap *compute.UrlMap) []string {
+	services := []string{urlMap.DefaultService}
+	routeActionServices := func(action *compute.HttpRouteAction) {
+		if action == nil {
+			return
+		}
+		for _, w := range action.WeightedBackendServices {
+			services = append(services, w.BackendService)
+		}
+	}
+
+	routeActionServices(urlMap.DefaultRouteAction)
+	for _, pm := range urlMap.PathMatchers {
+		services = append(services, pm.DefaultService)
+		routeActionServices(pm.DefaultRouteAction)
+		for _, pr := range pm.PathRules {
+			services = append(services, pr.Service)
+			routeActionServices(pr.RouteAction)
+		}
+		for _, rr := range pm.RouteRules {
+			services = append(services, rr.Service)
+			routeActionServices(rr.RouteAction)
+		}
+	}
+
+	paths := []string{}
+	for _, s := range lo.Uniq(services) {
+		if s != "" {
+			paths = append(paths, GetResourcePathFromURL(logger, s))
+		}
+	}
+	return paths
+}
+
+func (e *GCEForwardingRuleCollector) listLoadBalancerGraph(ctx context.Context, rules []*compute.ForwardingRule) (*loadBalancerGraph, error) {
+	g := &loadBalancerGraph{
+		proxies:          map[string]string{},
+		urlMaps:          map[string][]string{},
+		backendServices:  map[string][]string{},
+		groupSizes:       map[string]int64{},
+		targetPools:      map[string]int64{},
+		targetInstances:  map[string]string{},
+		runningInstances: map[string]bool{},
+	}
+	path := func(u string) string { return GetResourcePathFromURL(e.logger, u) }
+
+	calls := []func() error{
+		func() error {
+			return e.service.TargetHttpProxies.AggregatedList(e.project).Pages(ctx, func(page *compute.TargetHttpProxyAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, p := range scoped.TargetHttpProxies {
+						g.proxies[path(p.SelfLink)] = path(p.UrlMap)
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.TargetHttpsProxies.AggregatedList(e.project).Pages(ctx, func(page *compute.TargetHttpsProxyAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, p := range scoped.TargetHttpsProxies {
+						g.proxies[path(p.SelfLink)] = path(p.UrlMap)
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.TargetTcpProxies.AggregatedList(e.project).Pages(ctx, func(page *compute.TargetTcpProxyAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, p := range scoped.TargetTcpProxies {
+						g.proxies[path(p.SelfLink)] = path(p.Service)
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.TargetSslProxies.List(e.project).Pages(ctx, func(page *compute.TargetSslProxyList) error {
+				e.pagesFetched.Inc()
+				for _, p := range page.Items {
+					g.proxies[path(p.SelfLink)] = path(p.Service)
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.TargetGrpcProxies.List(e.project).Pages(ctx, func(page *compute.TargetGrpcProxyList) error {
+				e.pagesFetched.Inc()
+				for _, p := range page.Items {
+					g.proxies[path(p.SelfLink)] = path(p.UrlMap)
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.UrlMaps.AggregatedList(e.project).Pages(ctx, func(page *compute.UrlMapsAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, m := range scoped.UrlMaps {
+						g.urlMaps[path(m.SelfLink)] = urlMapServices(e.logger, m)
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.BackendServices.AggregatedList(e.project).Pages(ctx, func(page *compute.BackendServiceAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, s := range scoped.BackendServices {
+						groups := []string{}
+						for _, b := range s.Backends {
+							groups = append(groups, path(b.Group))
+						}
+						g.backendServices[path(s.SelfLink)] = groups
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.InstanceGroups.AggregatedList(e.project).Pages(ctx, func(page *compute.InstanceGroupAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, ig := range scoped.InstanceGroups {
+						g.groupSizes[path(ig.SelfLink)] = ig.Size
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.NetworkEndpointGroups.AggregatedList(e.project).Pages(ctx, func(page *compute.NetworkEndpointGroupAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, neg := range scoped.NetworkEndpointGroups {
+						g.groupSizes[path(neg.SelfLink)] = networkEndpointGroupSize(neg)
+					}
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.GlobalNetworkEndpointGroups.List(e.project).Pages(ctx, func(page *compute.NetworkEndpointGroupList) error {
+				e.pagesFetched.Inc()
+				for _, neg := range page.Items {
+					g.groupSizes[path(neg.SelfLink)] = networkEndpointGroupSize(neg)
+				}
+				return nil
+			})
+		},
+		func() error {
+			return e.service.TargetPools.AggregatedList(e.project).Pages(ctx, func(page *compute.TargetPoolAggregatedList) error {
+				e.pagesFetched.Inc()
+				for _, scoped := range page.Items {
+					for _, tp := range scoped.TargetPools {
+						g.targetPools[path(tp.SelfLink)] = int64(len(tp.Instances))
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	// Target instances are rare, so VMs are only listed when some rule points at one.
+	if lo.ContainsBy(rules, func(rule *compute.ForwardingRule) bool {
+		return resourceCollection(path(rule.Target)) == "targetInstances"
+	}) {
+		calls = append(calls,
+			func() error {
+				return e.service.TargetInstances.AggregatedList(e.project).Pages(ctx, func(page *compute.TargetInstanceAggregatedList) error {
+					e.pagesFetched.Inc()
+					for _, scoped := range page.Items {
+						for _, ti := range scoped.TargetInstances {
+							g.targetInstances[path(ti.SelfLink)] = path(ti.Instance)
+						}
+					}
+					return nil
+				})
+			},
+			func() error {
+				return e.service.Instances.AggregatedList(e.project).Filter(`status = "RUNNING"`).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+					e.pagesFetched.Inc()
+					for _, scoped := range page.Items {
+						for _, vm := range scoped.Instances {
+							g.runningInstances[path(vm.SelfLink)] = true
+						}
+					}
+					return nil
+				})
+			},
+		)
+	}
+
+	for _, call := range calls {
+		if err := call(); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// networkEndpointGroupSize returns how many endpoints a network endpoint group
+// has. Serverless and Private Service Connect groups point at a single service
+// and report no size.
+func networkEndpointGroupSize(neg *compute.NetworkEndpointGroup) int64 {
+	if neg.NetworkEndpointType == "SERVERLESS" || neg.NetworkEndpointType == "PRIVATE_SERVICE_CONNECT" {
+		return 1
+	}
+
+	return neg.Size
+}
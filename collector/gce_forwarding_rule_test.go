@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/log"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestGCEForwardingRuleCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_forwarding_rule collector", []string{"gce_forwarding_rule_has_backends", "gce_forwarding_rule_backends", "gce_forwarding_rule_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEForwardingRuleCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestLoadBalancerGraphBackends(t *testing.T) {
+	g := &loadBalancerGraph{
+		proxies: map[string]string{
+			"projects/p/global/targetHttpsProxies/web":   "projects/p/global/urlMaps/web",
+			"projects/p/global/targetTcpProxies/db":      "projects/p/global/backendServices/db",
+			"projects/p/global/targetHttpProxies/broken": "projects/p/global/urlMaps/deleted",
+		},
+		urlMaps: map[string][]string{
+			"projects/p/global/urlMaps/web": {"projects/p/global/backendServices/api", "projects/p/global/backendBuckets/static"},
+		},
+		backendServices: map[string][]string{
+			"projects/p/global/backendServices/api": {"projects/p/zones/us-east1-b/instanceGroups/api-b", "projects/p/zones/us-east1-c/networkEndpointGroups/api-c"},
+			"projects/p/global/backendServices/db":  {"projects/p/zones/us-east1-b/instanceGroups/db"},
+		},
+		groupSizes: map[string]int64{
+			"projects/p/zones/us-east1-b/instanceGroups/api-b":        2,
+			"projects/p/zones/us-east1-c/networkEndpointGroups/api-c": 3,
+			"projects/p/zones/us-east1-b/instanceGroups/db":           0,
+		},
+		targetPools: map[string]int64{
+			"projects/p/regions/us-east1/targetPools/legacy": 0,
+		},
+		targetInstances: map[string]string{
+			"projects/p/zones/us-east1-b/targetInstances/running": "projects/p/zones/us-east1-b/instances/running",
+			"projects/p/zones/us-east1-b/targetInstances/stopped": "projects/p/zones/us-east1-b/instances/stopped",
+			"projects/p/zones/us-east1-b/targetInstances/deleted": "projects/p/zones/us-east1-b/instances/deleted",
+		},
+		runningInstances: map[string]bool{
+			"projects/p/zones/us-east1-b/instances/running": true,
+		},
+	}
+
+	cases := []struct {
+		desc     string
+		input    string
+		expected int64
+		ok       bool
+	}{
+		{"should sum groups and buckets behind a URL map", "projects/p/global/targetHttpsProxies/web", 6, true},
+		{"should find no backends behind an empty backend service", "projects/p/global/targetTcpProxies/db", 0, true},
+		{"should count target pool instances", "projects/p/regions/us-east1/targetPools/legacy", 0, true},
+		{"should count a running target instance", "projects/p/zones/us-east1-b/targetInstances/running", 1, true},
+		{"should not count a stopped target instance", "projects/p/zones/us-east1-b/targetInstances/stopped", 0, true},
+		{"should not count the deleted VM of a target instance", "projects/p/zones/us-east1-b/targetInstances/deleted", 0, true},
+		{"should not know dangling references", "projects/p/global/targetHttpProxies/broken", 0, false},
+		{"should not know other targets", "projects/p/regions/us-east1/targetVpnGateways/vpn", 0, false},
+	}
+
+	for _, tc := range cases {
+		r, ok := g.backends(tc.input)
+		if r != tc.expected || ok != tc.ok {
+			t.Errorf("%s want %v %v got %v %v instead", tc.desc, tc.expected, tc.ok, r, ok)
+		}
+	}
+}
+
+func TestURLMapServices(t *testing.T) {
+	urlMap := &compute.UrlMap{
+		DefaultService: "https://www.googleapis.com/compute/v1/projects/p/global/backendServices/default",
+		PathMatchers: []*compute.PathMatcher{
+			{
+				DefaultService: "https://www.googleapis.com/compute/v1/projects/p/global/backendServices/default",
+				PathRules: []*compute.PathRule{
+					{Service: "https://www.googleapis.com/compute/v1/projects/p/global/backendBuckets/static"},
+				},
+				RouteRules: []*compute.HttpRouteRule{
+					{RouteAction: &compute.HttpRouteAction{WeightedBackendServices: []*compute.WeightedBackendService{
+						{BackendService: "https://www.googleapis.com/compute/v1/projects/p/global/backendServices/canary"},
+					}}},
+				},
+			},
+		},
+	}
+
+	expected := []string{"projects/p/global/backendServices/default", "projects/p/global/backendBuckets/static", "projects/p/global/backendServices/canary"}
+	if r := urlMapServices(log.NewJSONLogger(os.Stdout), urlMap); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v got %v", expected, r)
+	}
+}
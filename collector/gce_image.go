@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	imageAge          = prometheus.NewDesc("gce_image_age_days", "tells how many days the custom image or machine image has", []string{"project", "name", "image_type"}, nil)
+	imageStorageBytes = prometheus.NewDesc("gce_image_storage_bytes", "tells how many bytes of storage the custom image or machine image uses", []string{"project", "name", "image_type"}, nil)
+	imageInfo         = prometheus.NewDesc("gce_image_info", "tells the family, deprecation state and status of the custom image or machine image", []string{"project", "name", "image_type", "family", "deprecation_state", "status"}, nil)
+)
+
+type GCEImageCollector struct {
+	logger           log.Logger
+	service          *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_image", defaultEnabled, NewGCEImageCollector)
+}
+
+func (e *GCEImageCollector) ListMetrics() []string {
+	return []string{"gce_image_age_days", "gce_image_storage_bytes", "gce_image_info", "gce_image_labels"}
+}
+
+func NewGCEImageCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_image", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEImageCollector{
+		logger:           logger,
+		service:          computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_image", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gce_image_labels", "GCP labels of the custom image", []string{"project", "name", "image_type"}),
+	}, nil
+}
+
+func (e *GCEImageCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	err := e.service.Images.List(e.project).Pages(ctx, func(page *compute.ImageList) error {
+		e.pagesFetched.Inc()
+		for _, image := range page.Items {
+			if e.excluder.Excluded(image.Name, image.Labels) {
+				continue
+			}
+
+			e.report(ch, image.Name, "image", image.CreationTimestamp, image.ArchiveSizeBytes, now, image.Family, imageDeprecationState(image), image.Status)
+			e.labelsInfo.Collect(ch, image.Labels, e.project, image.Name, "image")
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting images for project %s", e.project), "err", err)
+		return err
+	}
+
+	err = e.service.MachineImages.List(e.project).Pages(ctx, func(page *compute.MachineImageList) error {
+		e.pagesFetched.Inc()
+		for _, machineImage := range page.Items {
+			// Machine images carry no labels in the compute v1 API, nor can they be deprecated.
+			if e.excluder.Excluded(machineImage.Name, nil) {
+				continue
+			}
+
+			e.report(ch, machineImage.Name, "machine_image", machineImage.CreationTimestamp, machineImage.TotalStorageBytes, now, "", "ACTIVE", machineImage.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machine images for project %s", e.project), "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (e *GCEImageCollector) report(ch chan<- prometheus.Metric, name string, imageType string, creationTimestamp string, storageBytes int64, now time.Time, family string, deprecationState string, status string) {
+	ch <- prometheus.MustNewConstMetric(imageInfo, prometheus.GaugeValue, 1.0, e.project, name, imageType, family, deprecationState, status)
+	ch <- prometheus.MustNewConstMetric(imageStorageBytes, prometheus.GaugeValue, float64(storageBytes), e.project, name, imageType)
+
+	age, err := ageInDays(creationTimestamp, now)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error parsing %s %s's CreationTimestamp for project %s", name, imageType, e.project), "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(imageAge, prometheus.GaugeValue, age, e.project, name, imageType)
+}
+
+// imageDeprecationState returns the deprecation state of an image, which is
+// ACTIVE unless it has been deprecated.
+func imageDeprecationState(image *compute.Image) string {
+	if image.Deprecated != nil && image.Deprecated.State != "" {
+		return image.Deprecated.State
+	}
+	return "ACTIVE"
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestGCEImageCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_image collector", []string{"gce_image_age_days", "gce_image_storage_bytes", "gce_image_info", "gce_image_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEImageCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestImageDeprecationState(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *compute.Image
+		expected string
+	}{
+		{"should be active when never deprecated", &compute.Image{}, "ACTIVE"},
+		{"should be active when the deprecation has no state", &compute.Image{Deprecated: &compute.DeprecationStatus{}}, "ACTIVE"},
+		{"should be active once undeprecated", &compute.Image{Deprecated: &compute.DeprecationStatus{State: "ACTIVE"}}, "ACTIVE"},
+		{"should be deprecated", &compute.Image{Deprecated: &compute.DeprecationStatus{State: "DEPRECATED"}}, "DEPRECATED"},
+		{"should be obsolete", &compute.Image{Deprecated: &compute.DeprecationStatus{State: "OBSOLETE"}}, "OBSOLETE"},
+		{"should be deleted", &compute.Image{Deprecated: &compute.DeprecationStatus{State: "DELETED"}}, "DELETED"},
+	}
+
+	for _, tc := range cases {
+		if r := imageDeprecationState(tc.input); r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
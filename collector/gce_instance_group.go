@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	instanceGroupTargetSize    = prometheus.NewDesc("gce_instance_group_target_size", "tells how many instances the managed instance group is meant to run", []string{"project", "location", "name"}, nil)
+	instanceGroupCurrentSize   = prometheus.NewDesc("gce_instance_group_current_size", "tells how many instances the managed instance group currently runs", []string{"project", "location", "name"}, nil)
+	instanceGroupHasAutoscaler = prometheus.NewDesc("gce_instance_group_has_autoscaler", "tells whether an autoscaler sizes the managed instance group", []string{"project", "location", "name"}, nil)
+	instanceTemplateInUse      = prometheus.NewDesc("gce_instance_template_in_use", "tells whether any managed instance group uses the instance template", []string{"project", "region", "name"}, nil)
+)
+
+type GCEInstanceGroupCollector struct {
+	logger           log.Logger
+	service          *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_instance_group", defaultEnabled, NewGCEInstanceGroupCollector)
+}
+
+func (e *GCEInstanceGroupCollector) ListMetrics() []string {
+	return []string{"gce_instance_group_target_size", "gce_instance_group_current_size", "gce_instance_group_has_autoscaler", "gce_instance_template_in_use"}
+}
+
+func NewGCEInstanceGroupCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_instance_group", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEInstanceGroupCollector{
+		logger:           logger,
+		service:          computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_instance_group", project),
+		excluder:         excluder,
+	}, nil
+}
+
+func (e *GCEInstanceGroupCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+
+	// Every managed instance group is listed, so that templates used outside
+	// of the monitored regions aren't reported as unused.
+	igms := []*compute.InstanceGroupManager{}
+	err := e.service.InstanceGroupManagers.AggregatedList(e.project).Pages(ctx, func(page *compute.InstanceGroupManagerAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			igms = append(igms, scoped.InstanceGroupManagers...)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting managed instance groups for project %s", e.project), "err", err)
+		return err
+	}
+
+	currentSizes, err := e.listInstanceGroupSizes(ctx)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting instance groups for project %s, current sizes won't be reported", e.project), "err", err)
+	}
+
+	usedTemplates := map[string]bool{}
+	for _, igm := range igms {
+		for _, t := range instanceGroupManagerTemplates(igm) {
+			usedTemplates[GetResourcePathFromURL(e.logger, t)] = true
+		}
+
+		location := GetGCPZoneFromURL(e.logger, igm.Zone)
+		if location == "" {
+			location = GetGCPRegionFromURL(e.logger, igm.Region)
+		}
+		// Managed instance groups carry no labels in the compute v1 API.
+		if !lo.Contains(e.monitoredRegions, GetGCPRegionFromLocation(location)) || e.excluder.Excluded(igm.Name, nil) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(instanceGroupTargetSize, prometheus.GaugeValue, float64(igm.TargetSize), e.project, location, igm.Name)
+
+		if size, ok := currentSizes[GetResourcePathFromURL(e.logger, igm.InstanceGroup)]; ok {
+			ch <- prometheus.MustNewConstMetric(instanceGroupCurrentSize, prometheus.GaugeValue, float64(size), e.project, location, igm.Name)
+		}
+
+		var hasAutoscaler float64
+		if igm.Status != nil && igm.Status.Autoscaler != "" {
+			hasAutoscaler = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(instanceGroupHasAutoscaler, prometheus.GaugeValue, hasAutoscaler, e.project, location, igm.Name)
+	}
+
+	err = e.service.InstanceTemplates.AggregatedList(e.project).Pages(ctx, func(page *compute.InstanceTemplateAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			for _, template := range scoped.InstanceTemplates {
+				region := GetGCPRegionFromURL(e.logger, template.Region)
+				if region == "" {
+					region = "global"
+				} else if !lo.Contains(e.monitoredRegions, region) {
+					continue
+				}
+				// Template properties hold the labels of the instances, not the template's.
+				if e.excluder.Excluded(template.Name, nil) {
+					continue
+				}
+
+				var inUse float64
+				if usedTemplates[GetResourcePathFromURL(e.logger, template.SelfLink)] {
+					inUse = 1.0
+				}
+				ch <- prometheus.MustNewConstMetric(instanceTemplateInUse, prometheus.GaugeValue, inUse, e.project, region, template.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting instance templates for project %s", e.project), "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// instanceGroupManagerTemplates returns the instance templates of every version of a managed instance group.
+func instanceGroupManagerTemplates(igm *compute.InstanceGroupManager) []string {
+	templates := []string{}
+	if igm.InstanceTemplate != "" {
+		templates = append(templates, igm.InstanceTemplate)
+	}
+	for _, v := range igm.Versions {
+		if v.InstanceTemplate != "" {
+			templates = append(templates, v.InstanceTemplate)
+		}
+	}
+	return lo.Uniq(templates)
+}
+
+// listInstanceGroupSizes returns the number of instances of every instance group by resource path.
+func (e *GCEInstanceGroupCollector) listInstanceGroupSizes(ctx context.Context) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	err := e.service.InstanceGroups.AggregatedList(e.project).Pages(ctx, func(page *compute.InstanceGroupAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			for _, ig := range scoped.InstanceGroups {
+				sizes[GetResourcePathFromURL(e.logger, ig.SelfLink)] = ig.Size
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sizes, nil
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestGCEInstanceGroupCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_instance_group collector", []string{"gce_instance_group_target_size", "gce_instance_group_current_size", "gce_instance_group_has_autoscaler", "gce_instance_template_in_use"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEInstanceGroupCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestInstanceGroupManagerTemplates(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *compute.InstanceGroupManager
+		expected []string
+	}{
+		{
+			"should return the template of a single version group",
+			&compute.InstanceGroupManager{InstanceTemplate: "global/instanceTemplates/a"},
+			[]string{"global/instanceTemplates/a"},
+		},
+		{
+			"should return the templates of every version once",
+			&compute.InstanceGroupManager{
+				InstanceTemplate: "global/instanceTemplates/a",
+				Versions: []*compute.InstanceGroupManagerVersion{
+					{InstanceTemplate: "global/instanceTemplates/a"},
+					{InstanceTemplate: "global/instanceTemplates/canary"},
+				},
+			},
+			[]string{"global/instanceTemplates/a", "global/instanceTemplates/canary"},
+		},
+	}
+
+	for _, tc := range cases {
+		r := instanceGroupManagerTemplates(tc.input)
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,7 +16,16 @@ import (
 )
 
 var (
-	isDiskAttached = prometheus.NewDesc("gce_is_disk_attached", "tells whether the Disk is attached to some machine", []string{"project", "zone", "name"}, nil)
+	gceIsDiskAttachedListStrategy = kingpin.Flag(
+		"collector.gce_is_disk_attached.list-strategy",
+		"How to list disks: aggregated (one call per project) or zonal (one call per zone of the monitored regions).",
+	).Default("aggregated").Enum("aggregated", "zonal")
+
+	isDiskAttached          = prometheus.NewDesc("gce_is_disk_attached", "tells whether the Disk is attached to some machine", []string{"project", "zone", "name"}, nil)
+	diskSizeGB              = prometheus.NewDesc("gce_disk_size_gb", "tells the size of the Disk in GB", []string{"project", "zone", "name", "type"}, nil)
+	diskLastAttachTimestamp = prometheus.NewDesc("gce_disk_last_attach_timestamp_seconds", "tells when the Disk was last attached to some machine", []string{"project", "zone", "name", "type"}, nil)
+	diskLastDetachTimestamp = prometheus.NewDesc("gce_disk_last_detach_timestamp_seconds", "tells when the Disk was last detached from some machine", []string{"project", "zone", "name", "type"}, nil)
+	diskCreationTimestamp   = prometheus.NewDesc("gce_disk_creation_timestamp_seconds", "tells when the Disk was created", []string{"project", "zone", "name", "type"}, nil)
 )
 
 type GCEIsDiskAttachedCollector struct {
@@ -22,6 +33,10 @@ type GCEIsDiskAttachedCollector struct {
 	service          *compute.Service
 	project          string
 	monitoredRegions []string
+	listStrategy     string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
 	mutex            sync.RWMutex
 }
 
@@ -30,10 +45,15 @@ func init() {
 }
 
 func (e *GCEIsDiskAttachedCollector) ListMetrics() []string {
-	return []string{"gce_is_disk_attached"}
+	return []string{"gce_is_disk_attached", "gce_disk_size_gb", "gce_disk_last_attach_timestamp_seconds", "gce_disk_last_detach_timestamp_seconds", "gce_disk_creation_timestamp_seconds", "gce_disk_labels", "gcp_idle_resource_estimated_monthly_cost"}
 }
 
 func NewIsDiskAttachedCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_is_disk_attached", project)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
 	if err != nil {
@@ -50,6 +70,10 @@ func NewIsDiskAttachedCollector(logger log.Logger, project string, monitoredRegi
 		service:          computeService,
 		project:          project,
 		monitoredRegions: monitoredRegions,
+		listStrategy:     *gceIsDiskAttachedListStrategy,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_is_disk_attached", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gce_disk_labels", "GCP labels of the Disk", []string{"project", "zone", "name"}),
 	}, nil
 }
 
@@ -57,39 +81,23 @@ func (e *GCEIsDiskAttachedCollector) Update(ch chan<- prometheus.Metric) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	regionList, err := e.service.Regions.List(e.project).Do()
+	ctx := context.Background()
+	var disks []*compute.Disk
+	var err error
+	if e.listStrategy == "zonal" {
+		disks, err = e.listZonalDisks(ctx)
+	} else {
+		disks, err = e.listAggregatedDisks(ctx)
+	}
 	if err != nil {
-		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
-		regionList = nil
+		return err
 	}
 
-	disks := []*compute.Disk{}
-
-	for _, r := range regionList.Items {
-		if !lo.Contains(e.monitoredRegions, r.Name) {
+	for _, disk := range disks {
+		if e.excluder.Excluded(disk.Name, disk.Labels) {
 			continue
 		}
 
-		var wgZones sync.WaitGroup
-		wgZones.Add(len(r.Zones))
-
-		for _, z := range r.Zones {
-			zone := GetGCPZoneFromURL(e.logger, z)
-			go func(ch chan<- prometheus.Metric) {
-				regionalDisks, err := e.service.Disks.List(e.project, zone).Do()
-				if err != nil {
-					level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machine disks for project %s in zone %s", e.project, zone), "err", err)
-					wgZones.Done()
-					return
-				}
-				disks = append(disks, regionalDisks.Items...)
-				wgZones.Done()
-			}(ch)
-		}
-		wgZones.Wait()
-	}
-
-	for _, disk := range disks {
 		isAttached := float64(len(disk.Users))
 		ch <- prometheus.MustNewConstMetric(
 			isDiskAttached,
@@ -98,7 +106,110 @@ func (e *GCEIsDiskAttachedCollector) Update(ch chan<- prometheus.Metric) error {
 			e.project,
 			GetGCPZoneFromURL(e.logger, disk.Zone),
 			disk.Name)
+
+		diskType := GetResourceNameFromURL(e.logger, disk.Type)
+		ch <- prometheus.MustNewConstMetric(
+			diskSizeGB,
+			prometheus.GaugeValue,
+			float64(disk.SizeGb),
+			e.project,
+			GetGCPZoneFromURL(e.logger, disk.Zone),
+			disk.Name,
+			diskType)
+
+		for desc, timestamp := range map[*prometheus.Desc]string{
+			diskLastAttachTimestamp: disk.LastAttachTimestamp,
+			diskLastDetachTimestamp: disk.LastDetachTimestamp,
+			// Disks which were never attached have no attachment timestamps.
+			diskCreationTimestamp: disk.CreationTimestamp,
+		} {
+			if timestamp == "" {
+				continue
+			}
+
+			seconds, err := unixTimestamp(timestamp)
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error parsing %s disk's timestamps for project %s", disk.Name, e.project), "err", err)
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				desc,
+				prometheus.GaugeValue,
+				seconds,
+				e.project,
+				GetGCPZoneFromURL(e.logger, disk.Zone),
+				disk.Name,
+				diskType)
+		}
+
+		if len(disk.Users) == 0 {
+			collectIdleResourceCost(ch, e.logger, func(p pricing.Pricer) (float64, error) {
+				price, err := p.DiskGBMonth(GetGCPRegionFromZone(GetGCPZoneFromURL(e.logger, disk.Zone)), diskType)
+				return price * float64(disk.SizeGb), err
+			}, e.project, GetGCPZoneFromURL(e.logger, disk.Zone), "disk", disk.Name)
+		}
+
+		e.labelsInfo.Collect(ch, disk.Labels, e.project, GetGCPZoneFromURL(e.logger, disk.Zone), disk.Name)
 	}
 
 	return nil
 }
+
+// listAggregatedDisks lists machine disks of every zone with a single aggregated
+// call and keeps the ones in the monitored regions.
+func (e *GCEIsDiskAttachedCollector) listAggregatedDisks(ctx context.Context) ([]*compute.Disk, error) {
+	disks := []*compute.Disk{}
+	err := e.service.Disks.AggregatedList(e.project).Pages(ctx, func(page *compute.DiskAggregatedList) error {
+		e.pagesFetched.Inc()
+		for scope, scoped := range page.Items {
+			zone := GetGCPZoneFromScope(scope)
+			if zone == "" || !lo.Contains(e.monitoredRegions, GetGCPRegionFromZone(zone)) {
+				continue
+			}
+			disks = append(disks, scoped.Disks...)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machine disks for project %s", e.project), "err", err)
+		return nil, err
+	}
+
+	return disks, nil
+}
+
+// listZonalDisks lists machine disks with one call per zone of the monitored regions.
+func (e *GCEIsDiskAttachedCollector) listZonalDisks(ctx context.Context) ([]*compute.Disk, error) {
+	zones, err := ListMonitoredZones(ctx, e.logger, e.service, e.project, e.monitoredRegions, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
+		return nil, err
+	}
+
+	disks := []*compute.Disk{}
+	var disksMutex sync.Mutex
+
+	var wgZones sync.WaitGroup
+	wgZones.Add(len(zones))
+
+	for _, zone := range zones {
+		go func(zone string) {
+			defer wgZones.Done()
+
+			err := e.service.Disks.List(e.project, zone).Pages(ctx, func(page *compute.DiskList) error {
+				e.pagesFetched.Inc()
+				disksMutex.Lock()
+				disks = append(disks, page.Items...)
+				disksMutex.Unlock()
+				return nil
+			})
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machine disks for project %s in zone %s", e.project, zone), "err", err)
+			}
+		}(zone)
+	}
+	wgZones.Wait()
+
+	return disks, nil
+}
@@ -10,7 +10,7 @@ func TestGCEIsDiskAttachedCollectorListMetrics(t *testing.T) {
 		desc     string
 		expected []string
 	}{
-		{"should list available metrics for gce_is_disk_attached collector", []string{"gce_is_disk_attached"}},
+		{"should list available metrics for gce_is_disk_attached collector", []string{"gce_is_disk_attached", "gce_disk_size_gb", "gce_disk_last_attach_timestamp_seconds", "gce_disk_last_detach_timestamp_seconds", "gce_disk_creation_timestamp_seconds", "gce_disk_labels", "gcp_idle_resource_estimated_monthly_cost"}},
 	}
 
 	for _, tc := range cases {
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,7 +17,14 @@ import (
 )
 
 var (
-	isMachineRunning = prometheus.NewDesc("gce_is_machine_running", "tells whether the VM is running", []string{"project", "zone", "name"}, nil)
+	gceIsMachineRunningListStrategy = kingpin.Flag(
+		"collector.gce_is_machine_running.list-strategy",
+		"How to list machines: aggregated (one call per project) or zonal (one call per zone of the monitored regions).",
+	).Default("aggregated").Enum("aggregated", "zonal")
+
+	isMachineRunning      = prometheus.NewDesc("gce_is_machine_running", "tells whether the VM is running", []string{"project", "zone", "name"}, nil)
+	machineStoppedSeconds = prometheus.NewDesc("gce_machine_stopped_seconds", "tells how many seconds ago the VM which is not running was stopped or suspended", []string{"project", "zone", "name", "status"}, nil)
+	machineRunningSeconds = prometheus.NewDesc("gce_machine_running_seconds", "tells how many seconds ago the running VM was started", []string{"project", "zone", "name"}, nil)
 )
 
 type GCEIsMachineRunningCollector struct {
@@ -22,6 +32,10 @@ type GCEIsMachineRunningCollector struct {
 	service          *compute.Service
 	project          string
 	monitoredRegions []string
+	listStrategy     string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
 	mutex            sync.RWMutex
 }
 
@@ -30,10 +44,15 @@ func init() {
 }
 
 func (e *GCEIsMachineRunningCollector) ListMetrics() []string {
-	return []string{"gce_is_machine_running"}
+	return []string{"gce_is_machine_running", "gce_machine_stopped_seconds", "gce_machine_running_seconds", "gce_instance_labels", "gcp_idle_resource_estimated_monthly_cost"}
 }
 
 func NewGCEIsMachineRunningCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_is_machine_running", project)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
 	if err != nil {
@@ -50,6 +69,10 @@ func NewGCEIsMachineRunningCollector(logger log.Logger, project string, monitore
 		service:          computeService,
 		project:          project,
 		monitoredRegions: monitoredRegions,
+		listStrategy:     *gceIsMachineRunningListStrategy,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_is_machine_running", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gce_instance_labels", "GCP labels of the VM", []string{"project", "zone", "name"}),
 	}, nil
 }
 
@@ -57,39 +80,33 @@ func (e *GCEIsMachineRunningCollector) Update(ch chan<- prometheus.Metric) error
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	regionList, err := e.service.Regions.List(e.project).Do()
+	ctx := context.Background()
+	var vms []*compute.Instance
+	var err error
+	if e.listStrategy == "zonal" {
+		vms, err = e.listZonalInstances(ctx)
+	} else {
+		vms, err = e.listAggregatedInstances(ctx)
+	}
 	if err != nil {
-		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
-		regionList = nil
+		return err
 	}
 
-	vms := []*compute.Instance{}
-
-	for _, r := range regionList.Items {
-		if !lo.Contains(e.monitoredRegions, r.Name) {
-			continue
-		}
-
-		var wgZones sync.WaitGroup
-		wgZones.Add(len(r.Zones))
-
-		for _, z := range r.Zones {
-			zone := GetGCPZoneFromURL(e.logger, z)
-			go func(ch chan<- prometheus.Metric) {
-				regionalInstances, err := e.service.Instances.List(e.project, zone).Do()
-				if err != nil {
-					level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machines for project %s in zone %s", e.project, zone), "err", err)
-					wgZones.Done()
-					return
-				}
-				vms = append(vms, regionalInstances.Items...)
-				wgZones.Done()
-			}(ch)
+	// Disk types are only needed to estimate how much stopped VMs cost.
+	var diskTypes map[string]string
+	if Pricer != nil && lo.ContainsBy(vms, isMachineStopped) {
+		diskTypes, err = e.listDiskTypes(ctx)
+		if err != nil {
+			level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting disks for project %s, stopped VMs' cost won't be reported", e.project), "err", err)
 		}
-		wgZones.Wait()
 	}
 
+	now := time.Now()
 	for _, vm := range vms {
+		if e.excluder.Excluded(vm.Name, vm.Labels) {
+			continue
+		}
+
 		var isRunning float64
 		if vm.Status == "RUNNING" {
 			isRunning = 1.0
@@ -104,7 +121,172 @@ func (e *GCEIsMachineRunningCollector) Update(ch chan<- prometheus.Metric) error
 			e.project,
 			GetGCPZoneFromURL(e.logger, vm.Zone),
 			vm.Name)
+
+		e.reportStateDuration(ch, vm, now)
+		if diskTypes != nil && isMachineStopped(vm) {
+			collectIdleResourceCost(ch, e.logger, func(p pricing.Pricer) (float64, error) {
+				return e.estimateStoppedMachineCost(p, vm, diskTypes)
+			}, e.project, GetGCPZoneFromURL(e.logger, vm.Zone), "instance", vm.Name)
+		}
+		e.labelsInfo.Collect(ch, vm.Labels, e.project, GetGCPZoneFromURL(e.logger, vm.Zone), vm.Name)
 	}
 
 	return nil
 }
+
+// reportStateDuration tells for how long the VM has been running, or stopped
+// when it isn't running, so that long-stopped machines can be told apart.
+func (e *GCEIsMachineRunningCollector) reportStateDuration(ch chan<- prometheus.Metric, vm *compute.Instance, now time.Time) {
+	zone := GetGCPZoneFromURL(e.logger, vm.Zone)
+
+	var desc *prometheus.Desc
+	var timestamp string
+	var labels []string
+	switch vm.Status {
+	case "RUNNING":
+		desc, timestamp, labels = machineRunningSeconds, vm.LastStartTimestamp, []string{e.project, zone, vm.Name}
+	case "STOPPED", "TERMINATED":
+		desc, timestamp, labels = machineStoppedSeconds, vm.LastStopTimestamp, []string{e.project, zone, vm.Name, vm.Status}
+	case "SUSPENDED":
+		desc, timestamp, labels = machineStoppedSeconds, vm.LastSuspendedTimestamp, []string{e.project, zone, vm.Name, vm.Status}
+	default:
+		// Transitional states such as STAGING or STOPPING.
+		return
+	}
+
+	if timestamp == "" {
+		// The VM has never gone through that state change.
+		return
+	}
+
+	seconds, err := secondsSince(timestamp, now)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error parsing %s VM's state change timestamp for project %s", vm.Name, e.project), "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, seconds, labels...)
+}
+
+func isMachineStopped(vm *compute.Instance) bool {
+	return lo.Contains([]string{"STOPPED", "TERMINATED", "SUSPENDED"}, vm.Status)
+}
+
+// estimateStoppedMachineCost sums the monthly cost of the persistent disks and
+// static IP addresses a stopped VM keeps being billed for.
+func (e *GCEIsMachineRunningCollector) estimateStoppedMachineCost(p pricing.Pricer, vm *compute.Instance, diskTypes map[string]string) (float64, error) {
+	region := GetGCPRegionFromZone(GetGCPZoneFromURL(e.logger, vm.Zone))
+
+	var cost float64
+	for _, d := range vm.Disks {
+		if d.Type != "PERSISTENT" {
+			continue
+		}
+
+		// Disks created since the listing are left out rather than the whole VM.
+		diskType, ok := diskTypes[GetResourcePathFromURL(e.logger, d.Source)]
+		if !ok {
+			level.Warn(e.logger).Log("msg", fmt.Sprintf("unknown type of %s VM's disk for project %s, its cost is left out", vm.Name, e.project), "disk", d.Source)
+			continue
+		}
+		price, err := p.DiskGBMonth(region, diskType)
+		if err != nil {
+			return 0, err
+		}
+		cost += price * float64(d.DiskSizeGb)
+	}
+
+	// Ephemeral external IPs are released when the VM stops, only static ones remain.
+	for _, ni := range vm.NetworkInterfaces {
+		for _, ac := range ni.AccessConfigs {
+			if ac.NatIP == "" {
+				continue
+			}
+			price, err := p.StaticIPMonth(region)
+			if err != nil {
+				return 0, err
+			}
+			cost += price
+		}
+	}
+
+	return cost, nil
+}
+
+// listDiskTypes returns the type of every zonal and regional disk of the
+// project by resource path, as either can be attached to VMs.
+func (e *GCEIsMachineRunningCollector) listDiskTypes(ctx context.Context) (map[string]string, error) {
+	diskTypes := map[string]string{}
+	err := e.service.Disks.AggregatedList(e.project).Pages(ctx, func(page *compute.DiskAggregatedList) error {
+		e.pagesFetched.Inc()
+		for _, scoped := range page.Items {
+			for _, disk := range scoped.Disks {
+				diskTypes[GetResourcePathFromURL(e.logger, disk.SelfLink)] = GetResourceNameFromURL(e.logger, disk.Type)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return diskTypes, nil
+}
+
+// listAggregatedInstances lists machines of every zone with a single aggregated
+// call and keeps the ones in the monitored regions.
+func (e *GCEIsMachineRunningCollector) listAggregatedInstances(ctx context.Context) ([]*compute.Instance, error) {
+	vms := []*compute.Instance{}
+	err := e.service.Instances.AggregatedList(e.project).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		e.pagesFetched.Inc()
+		for scope, scoped := range page.Items {
+			zone := GetGCPZoneFromScope(scope)
+			if zone == "" || !lo.Contains(e.monitoredRegions, GetGCPRegionFromZone(zone)) {
+				continue
+			}
+			vms = append(vms, scoped.Instances...)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machines for project %s", e.project), "err", err)
+		return nil, err
+	}
+
+	return vms, nil
+}
+
+// listZonalInstances lists machines with one call per zone of the monitored regions.
+func (e *GCEIsMachineRunningCollector) listZonalInstances(ctx context.Context) ([]*compute.Instance, error) {
+	zones, err := ListMonitoredZones(ctx, e.logger, e.service, e.project, e.monitoredRegions, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
+		return nil, err
+	}
+
+	vms := []*compute.Instance{}
+	var vmsMutex sync.Mutex
+
+	var wgZones sync.WaitGroup
+	wgZones.Add(len(zones))
+
+	for _, zone := range zones {
+		go func(zone string) {
+			defer wgZones.Done()
+
+			err := e.service.Instances.List(e.project, zone).Pages(ctx, func(page *compute.InstanceList) error {
+				e.pagesFetched.Inc()
+				vmsMutex.Lock()
+				vms = append(vms, page.Items...)
+				vmsMutex.Unlock()
+				return nil
+			})
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machines for project %s in zone %s", e.project, zone), "err", err)
+			}
+		}(zone)
+	}
+	wgZones.Wait()
+
+	return vms, nil
+}
@@ -1,8 +1,13 @@
 package collector
 
 import (
+	"math"
+	"os"
 	"reflect"
 	"testing"
+
+	"github.com/go-kit/log"
+	"google.golang.org/api/compute/v1"
 )
 
 func TestGCEIsMachineRunningCollectorListMetrics(t *testing.T) {
@@ -10,7 +15,7 @@ func TestGCEIsMachineRunningCollectorListMetrics(t *testing.T) {
 		desc     string
 		expected []string
 	}{
-		{"should list available metrics for gce_is_machine_running collector", []string{"gce_is_machine_running"}},
+		{"should list available metrics for gce_is_machine_running collector", []string{"gce_is_machine_running", "gce_machine_stopped_seconds", "gce_machine_running_seconds", "gce_instance_labels", "gcp_idle_resource_estimated_monthly_cost"}},
 	}
 
 	for _, tc := range cases {
@@ -20,3 +25,52 @@ func TestGCEIsMachineRunningCollectorListMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimateStoppedMachineCost(t *testing.T) {
+	diskTypes := map[string]string{
+		"projects/project/zones/us-east1-b/disks/zonal":    "pd-standard",
+		"projects/project/regions/us-east1/disks/regional": "pd-standard",
+	}
+	attached := func(source string) *compute.AttachedDisk {
+		return &compute.AttachedDisk{Type: "PERSISTENT", Source: "https://www.googleapis.com/compute/v1/" + source, DiskSizeGb: 100}
+	}
+
+	cases := []struct {
+		desc     string
+		input    *compute.Instance
+		expected float64
+	}{
+		{
+			"should price zonal and regional disks",
+			&compute.Instance{Zone: "us-east1-b", Disks: []*compute.AttachedDisk{
+				attached("projects/project/zones/us-east1-b/disks/zonal"),
+				attached("projects/project/regions/us-east1/disks/regional"),
+			}},
+			8,
+		},
+		{
+			"should leave out scratch disks and disks of unknown type",
+			&compute.Instance{Zone: "us-east1-b", Disks: []*compute.AttachedDisk{
+				attached("projects/project/zones/us-east1-b/disks/zonal"),
+				attached("projects/project/zones/us-east1-b/disks/unknown"),
+				{Type: "SCRATCH", DiskSizeGb: 375},
+			}},
+			4,
+		},
+		{
+			"should price static IP addresses",
+			&compute.Instance{Zone: "us-east1-b", NetworkInterfaces: []*compute.NetworkInterface{
+				{AccessConfigs: []*compute.AccessConfig{{NatIP: "203.0.113.1"}, {}}},
+			}},
+			7.3,
+		},
+	}
+
+	collector := GCEIsMachineRunningCollector{logger: log.NewJSONLogger(os.Stdout), project: "project"}
+	for _, tc := range cases {
+		r, err := collector.estimateStoppedMachineCost(fakePricer{}, tc.input, diskTypes)
+		if err != nil || math.Abs(r-tc.expected) > 1e-9 {
+			t.Errorf("%s want %v got %v %v instead", tc.desc, tc.expected, r, err)
+		}
+	}
+}
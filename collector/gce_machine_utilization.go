@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+)
+
+var (
+	gceMachineUtilizationLookback = kingpin.Flag(
+		"collector.gce_machine_utilization.lookback",
+		"Window over which the utilization of running VMs is averaged.",
+	).Default("24h").Duration()
+
+	gceMachineUtilizationCPUThreshold = kingpin.Flag(
+		"collector.gce_machine_utilization.cpu-threshold",
+		"Average CPU utilization, from 0 to 1, below which a running VM is considered idle.",
+	).Default("0.05").Float64()
+
+	gceMachineUtilizationNetworkThreshold = kingpin.Flag(
+		"collector.gce_machine_utilization.network-threshold",
+		"Average bytes per second, received and sent, below which a running VM is considered idle.",
+	).Default("1024").Float64()
+
+	machineIdleScore                     = prometheus.NewDesc("gce_machine_idle_score", "tells the fraction of the idleness criteria, low CPU and low network traffic, the running VM meets", []string{"project", "zone", "name"}, nil)
+	machineCPUUtilizationAverage         = prometheus.NewDesc("gce_machine_cpu_utilization_average", "tells the average CPU utilization, from 0 to 1, of the running VM over the lookback window", []string{"project", "zone", "name"}, nil)
+	machineNetworkReceivedBytesPerSecond = prometheus.NewDesc("gce_machine_network_received_bytes_per_second", "tells the average bytes per second the running VM received over the lookback window", []string{"project", "zone", "name"}, nil)
+	machineNetworkSentBytesPerSecond     = prometheus.NewDesc("gce_machine_network_sent_bytes_per_second", "tells the average bytes per second the running VM sent over the lookback window", []string{"project", "zone", "name"}, nil)
+)
+
+type GCEMachineUtilizationCollector struct {
+	logger            log.Logger
+	service           *compute.Service
+	monitoringService *monitoring.Service
+	project           string
+	monitoredRegions  []string
+	lookback          time.Duration
+	cpuThreshold      float64
+	networkThreshold  float64
+	pagesFetched      prometheus.Counter
+	excluder          *ResourceExcluder
+	mutex             sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_machine_utilization", defaultDisabled, NewGCEMachineUtilizationCollector)
+}
+
+func (e *GCEMachineUtilizationCollector) ListMetrics() []string {
+	return []string{"gce_machine_idle_score", "gce_machine_cpu_utilization_average", "gce_machine_network_received_bytes_per_second", "gce_machine_network_sent_bytes_per_second"}
+}
+
+func NewGCEMachineUtilizationCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_machine_utilization", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	monitoringClient, err := NewGCPClient(ctx, monitoring.MonitoringReadScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	monitoringService, err := monitoring.NewService(ctx, option.WithHTTPClient(monitoringClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEMachineUtilizationCollector{
+		logger:            logger,
+		service:           computeService,
+		monitoringService: monitoringService,
+		project:           project,
+		monitoredRegions:  monitoredRegions,
+		lookback:          *gceMachineUtilizationLookback,
+		cpuThreshold:      *gceMachineUtilizationCPUThreshold,
+		networkThreshold:  *gceMachineUtilizationNetworkThreshold,
+		pagesFetched:      apiPagesFetched.WithLabelValues("gce_machine_utilization", project),
+		excluder:          excluder,
+	}, nil
+}
+
+func (e *GCEMachineUtilizationCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	vms, err := e.listRunningInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cpu := e.queryByInstance(ctx, "cpu utilization", MonitoringQuery{
+		Filter:  `metric.type="compute.googleapis.com/instance/cpu/utilization" AND resource.type="gce_instance"`,
+		Aligner: "ALIGN_MEAN",
+		Reducer: "REDUCE_MEAN",
+		GroupBy: []string{"resource.label.instance_id"},
+	}, now)
+	received := e.queryByInstance(ctx, "received bytes", MonitoringQuery{
+		Filter:  `metric.type="compute.googleapis.com/instance/network/received_bytes_count" AND resource.type="gce_instance"`,
+		Aligner: "ALIGN_RATE",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.instance_id"},
+	}, now)
+	sent := e.queryByInstance(ctx, "sent bytes", MonitoringQuery{
+		Filter:  `metric.type="compute.googleapis.com/instance/network/sent_bytes_count" AND resource.type="gce_instance"`,
+		Aligner: "ALIGN_RATE",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.instance_id"},
+	}, now)
+
+	for _, vm := range vms {
+		if e.excluder.Excluded(vm.Name, vm.Labels) {
+			continue
+		}
+
+		zone := GetGCPZoneFromURL(e.logger, vm.Zone)
+		id := strconv.FormatUint(vm.Id, 10)
+
+		cpuAverage, hasCPU := cpu[id]
+		receivedRate, hasReceived := received[id]
+		sentRate, hasSent := sent[id]
+
+		if hasCPU {
+			ch <- prometheus.MustNewConstMetric(machineCPUUtilizationAverage, prometheus.GaugeValue, cpuAverage, e.project, zone, vm.Name)
+		}
+		if hasReceived {
+			ch <- prometheus.MustNewConstMetric(machineNetworkReceivedBytesPerSecond, prometheus.GaugeValue, receivedRate, e.project, zone, vm.Name)
+		}
+		if hasSent {
+			ch <- prometheus.MustNewConstMetric(machineNetworkSentBytesPerSecond, prometheus.GaugeValue, sentRate, e.project, zone, vm.Name)
+		}
+
+		if score, ok := machineIdleScoreOf(cpuAverage, hasCPU, receivedRate+sentRate, hasReceived || hasSent, e.cpuThreshold, e.networkThreshold); ok {
+			ch <- prometheus.MustNewConstMetric(machineIdleScore, prometheus.GaugeValue, score, e.project, zone, vm.Name)
+		}
+	}
+
+	return nil
+}
+
+// machineIdleScoreOf returns the fraction of the idleness criteria met among
+// the ones there is data for, if any.
+func machineIdleScoreOf(cpu float64, hasCPU bool, network float64, hasNetwork bool, cpuThreshold float64, networkThreshold float64) (float64, bool) {
+	var criteria, met float64
+	if hasCPU {
+		criteria++
+		if cpu < cpuThreshold {
+			met++
+		}
+	}
+	if hasNetwork {
+		criteria++
+		if network < networkThreshold {
+			met++
+		}
+	}
+
+	if criteria == 0 {
+		return 0, false
+	}
+	return met / criteria, true
+}
+
+// queryByInstance returns the values of a Cloud Monitoring query by instance ID.
+// Failures are logged so that the other queries can still be reported.
+func (e *GCEMachineUtilizationCollector) queryByInstance(ctx context.Context, what string, query MonitoringQuery, now time.Time) map[string]float64 {
+	samples, err := QueryMonitoring(ctx, e.monitoringService, e.project, query, e.lookback, now, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machines' %s for project %s", what, e.project), "err", err)
+		return nil
+	}
+
+	values := map[string]float64{}
+	for _, s := range samples {
+		values[s.ResourceLabels["instance_id"]] = s.Value
+	}
+	return values
+}
+
+// listRunningInstances lists running machines of every zone with a single
+// aggregated call and keeps the ones in the monitored regions.
+func (e *GCEMachineUtilizationCollector) listRunningInstances(ctx context.Context) ([]*compute.Instance, error) {
+	vms := []*compute.Instance{}
+	err := e.service.Instances.AggregatedList(e.project).Filter(`status = "RUNNING"`).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		e.pagesFetched.Inc()
+		for scope, scoped := range page.Items {
+			zone := GetGCPZoneFromScope(scope)
+			if zone == "" || !lo.Contains(e.monitoredRegions, GetGCPRegionFromZone(zone)) {
+				continue
+			}
+			vms = append(vms, scoped.Instances...)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting machines for project %s", e.project), "err", err)
+		return nil, err
+	}
+
+	return vms, nil
+}
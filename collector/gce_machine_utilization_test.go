@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGCEMachineUtilizationCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_machine_utilization collector", []string{"gce_machine_idle_score", "gce_machine_cpu_utilization_average", "gce_machine_network_received_bytes_per_second", "gce_machine_network_sent_bytes_per_second"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEMachineUtilizationCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestMachineIdleScoreOf(t *testing.T) {
+	cases := []struct {
+		desc       string
+		cpu        float64
+		hasCPU     bool
+		network    float64
+		hasNetwork bool
+		expected   float64
+		ok         bool
+	}{
+		{"should have no score without data", 0, false, 0, false, 0, false},
+		{"should be idle with low CPU and network", 0.01, true, 10, true, 1, true},
+		{"should be half idle with low CPU only", 0.01, true, 1e6, true, 0.5, true},
+		{"should not be idle with high CPU and network", 0.9, true, 1e6, true, 0, true},
+		{"should score the CPU alone without network data", 0.01, true, 0, false, 1, true},
+	}
+
+	for _, tc := range cases {
+		r, ok := machineIdleScoreOf(tc.cpu, tc.hasCPU, tc.network, tc.hasNetwork, 0.05, 1024)
+		if r != tc.expected || ok != tc.ok {
+			t.Errorf("%s want %v %v got %v %v instead", tc.desc, tc.expected, tc.ok, r, ok)
+		}
+	}
+}
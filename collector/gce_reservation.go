@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	reservationReservedCount = prometheus.NewDesc("gce_reservation_reserved_count", "tells how many VMs the zonal reservation holds capacity for", []string{"project", "zone", "name"}, nil)
+	reservationInUseCount    = prometheus.NewDesc("gce_reservation_in_use_count", "tells how many VMs currently consume the zonal reservation", []string{"project", "zone", "name"}, nil)
+	reservationUtilization   = prometheus.NewDesc("gce_reservation_utilization_ratio", "tells which fraction of the zonal reservation is consumed", []string{"project", "zone", "name"}, nil)
+	reservationInfo          = prometheus.NewDesc("gce_reservation_info", "tells the machine type, status and commitment of the zonal reservation", []string{"project", "zone", "name", "machine_type", "status", "commitment", "specific_reservation_required"}, nil)
+)
+
+type GCEReservationCollector struct {
+	logger           log.Logger
+	service          *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gce_reservation", defaultEnabled, NewGCEReservationCollector)
+}
+
+func (e *GCEReservationCollector) ListMetrics() []string {
+	return []string{"gce_reservation_reserved_count", "gce_reservation_in_use_count", "gce_reservation_utilization_ratio", "gce_reservation_info"}
+}
+
+func NewGCEReservationCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gce_reservation", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, compute.ComputeReadonlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCEReservationCollector{
+		logger:           logger,
+		service:          computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gce_reservation", project),
+		excluder:         excluder,
+	}, nil
+}
+
+func (e *GCEReservationCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	zones, err := ListMonitoredZones(ctx, e.logger, e.service, e.project, e.monitoredRegions, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
+		return err
+	}
+
+	var wgZones sync.WaitGroup
+	wgZones.Add(len(zones))
+
+	for _, zone := range zones {
+		go func(zone string) {
+			defer wgZones.Done()
+
+			err := e.service.Reservations.List(e.project, zone).Pages(ctx, func(page *compute.ReservationList) error {
+				e.pagesFetched.Inc()
+				for _, reservation := range page.Items {
+					// Reservations carry no labels in the compute v1 API.
+					if reservation.SpecificReservation == nil || e.excluder.Excluded(reservation.Name, nil) {
+						continue
+					}
+					e.report(ch, zone, reservation)
+				}
+				return nil
+			})
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting reservations for project %s in zone %s", e.project, zone), "err", err)
+			}
+		}(zone)
+	}
+	wgZones.Wait()
+
+	return nil
+}
+
+func (e *GCEReservationCollector) report(ch chan<- prometheus.Metric, zone string, reservation *compute.Reservation) {
+	sku := reservation.SpecificReservation
+
+	var machineType string
+	if sku.InstanceProperties != nil {
+		machineType = sku.InstanceProperties.MachineType
+	}
+	var commitment string
+	if reservation.Commitment != "" {
+		commitment = GetResourceNameFromURL(e.logger, reservation.Commitment)
+	}
+	ch <- prometheus.MustNewConstMetric(reservationInfo, prometheus.GaugeValue, 1.0, e.project, zone, reservation.Name, machineType, reservation.Status, commitment, strconv.FormatBool(reservation.SpecificReservationRequired))
+
+	ch <- prometheus.MustNewConstMetric(reservationReservedCount, prometheus.GaugeValue, float64(sku.Count), e.project, zone, reservation.Name)
+	ch <- prometheus.MustNewConstMetric(reservationInUseCount, prometheus.GaugeValue, float64(sku.InUseCount), e.project, zone, reservation.Name)
+
+	if ratio, ok := reservationUtilizationRatio(sku); ok {
+		ch <- prometheus.MustNewConstMetric(reservationUtilization, prometheus.GaugeValue, ratio, e.project, zone, reservation.Name)
+	}
+}
+
+// reservationUtilizationRatio returns the fraction of the reserved VMs in use,
+// unless nothing is reserved.
+func reservationUtilizationRatio(sku *compute.AllocationSpecificSKUReservation) (float64, bool) {
+	if sku.Count <= 0 {
+		return 0, false
+	}
+	return float64(sku.InUseCount) / float64(sku.Count), true
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestGCEReservationCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gce_reservation collector", []string{"gce_reservation_reserved_count", "gce_reservation_in_use_count", "gce_reservation_utilization_ratio", "gce_reservation_info"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCEReservationCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestReservationUtilizationRatio(t *testing.T) {
+	cases := []struct {
+		desc       string
+		input      *compute.AllocationSpecificSKUReservation
+		expected   float64
+		expectedOk bool
+	}{
+		{"should tell an unused reservation", &compute.AllocationSpecificSKUReservation{Count: 4}, 0, true},
+		{"should tell a partially used reservation", &compute.AllocationSpecificSKUReservation{Count: 4, InUseCount: 1}, 0.25, true},
+		{"should tell a fully used reservation", &compute.AllocationSpecificSKUReservation{Count: 4, InUseCount: 4}, 1, true},
+		{"should skip a reservation of nothing", &compute.AllocationSpecificSKUReservation{}, 0, false},
+	}
+
+	for _, tc := range cases {
+		ratio, ok := reservationUtilizationRatio(tc.input)
+		if ratio != tc.expected || ok != tc.expectedOk {
+			t.Errorf("%s want %v, %v got %v, %v instead", tc.desc, tc.expected, tc.expectedOk, ratio, ok)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// gcsStorageMetricsWindow covers the daily samples of the bucket size metrics.
+const gcsStorageMetricsWindow = 48 * time.Hour
+
+var (
+	gcsBucketLookback = kingpin.Flag(
+		"collector.gcs_bucket.lookback",
+		"Window over which requests to buckets are looked for.",
+	).Default("720h").Duration()
+
+	gcsBucketInfo               = prometheus.NewDesc("gcs_bucket_info", "tells the location and default storage class of the bucket", []string{"project", "name", "location", "storage_class"}, nil)
+	gcsBucketHasLifecyclePolicy = prometheus.NewDesc("gcs_bucket_has_lifecycle_policy", "tells whether the bucket has lifecycle rules deleting or moving its objects", []string{"project", "name"}, nil)
+	gcsBucketObjectCount        = prometheus.NewDesc("gcs_bucket_object_count", "tells how many objects the bucket holds", []string{"project", "name"}, nil)
+	gcsBucketTotalBytes         = prometheus.NewDesc("gcs_bucket_total_bytes", "tells how many bytes the objects of the bucket take", []string{"project", "name"}, nil)
+	gcsBucketRequests           = prometheus.NewDesc("gcs_bucket_requests", "tells how many API requests the bucket received over the lookback window", []string{"project", "name"}, nil)
+	gcsBucketLastRequestSeconds = prometheus.NewDesc("gcs_bucket_last_request_seconds", "tells how many seconds ago the bucket last received an API request, or the lookback window when it received none within it", []string{"project", "name"}, nil)
+)
+
+type GCSBucketCollector struct {
+	logger            log.Logger
+	service           *storage.Service
+	monitoringService *monitoring.Service
+	project           string
+	monitoredRegions  []string
+	lookback          time.Duration
+	pagesFetched      prometheus.Counter
+	excluder          *ResourceExcluder
+	labelsInfo        *ResourceLabelsInfo
+	mutex             sync.RWMutex
+}
+
+func init() {
+	registerCollector("gcs_bucket", defaultDisabled, NewGCSBucketCollector)
+}
+
+func (e *GCSBucketCollector) ListMetrics() []string {
+	return []string{"gcs_bucket_info", "gcs_bucket_has_lifecycle_policy", "gcs_bucket_object_count", "gcs_bucket_total_bytes", "gcs_bucket_requests", "gcs_bucket_last_request_seconds", "gcs_bucket_labels"}
+}
+
+func NewGCSBucketCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gcs_bucket", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, storage.DevstorageReadOnlyScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	storageService, err := storage.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	monitoringClient, err := NewGCPClient(ctx, monitoring.MonitoringReadScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	monitoringService, err := monitoring.NewService(ctx, option.WithHTTPClient(monitoringClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GCSBucketCollector{
+		logger:            logger,
+		service:           storageService,
+		monitoringService: monitoringService,
+		project:           project,
+		monitoredRegions:  monitoredRegions,
+		lookback:          *gcsBucketLookback,
+		pagesFetched:      apiPagesFetched.WithLabelValues("gcs_bucket", project),
+		excluder:          excluder,
+		labelsInfo:        NewResourceLabelsInfo("gcs_bucket_labels", "GCP labels of the bucket", []string{"project", "name"}),
+	}, nil
+}
+
+func (e *GCSBucketCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	buckets := []*storage.Bucket{}
+	err := e.service.Buckets.List(e.project).Pages(ctx, func(page *storage.Buckets) error {
+		e.pagesFetched.Inc()
+		for _, bucket := range page.Items {
+			if gcsBucketMonitored(bucket, e.monitoredRegions) {
+				buckets = append(buckets, bucket)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting buckets for project %s", e.project), "err", err)
+		return err
+	}
+
+	now := time.Now()
+	objectCounts := e.queryByBucket(ctx, "object counts", MonitoringQuery{
+		Filter:  `metric.type="storage.googleapis.com/storage/object_count" AND resource.type="gcs_bucket"`,
+		Aligner: "ALIGN_NEXT_OLDER",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.bucket_name"},
+	}, gcsStorageMetricsWindow, now)
+	totalBytes := e.queryByBucket(ctx, "sizes", MonitoringQuery{
+		Filter:  `metric.type="storage.googleapis.com/storage/total_bytes" AND resource.type="gcs_bucket"`,
+		Aligner: "ALIGN_NEXT_OLDER",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.bucket_name"},
+	}, gcsStorageMetricsWindow, now)
+	requests := e.queryByBucket(ctx, "requests", MonitoringQuery{
+		Filter:  `metric.type="storage.googleapis.com/api/request_count" AND resource.type="gcs_bucket"`,
+		Aligner: "ALIGN_SUM",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.bucket_name"},
+	}, e.lookback, now)
+	// Hourly points tell when the last request happened.
+	hourlyRequests := e.queryByBucket(ctx, "hourly requests", MonitoringQuery{
+		Filter:          `metric.type="storage.googleapis.com/api/request_count" AND resource.type="gcs_bucket"`,
+		Aligner:         "ALIGN_SUM",
+		Reducer:         "REDUCE_SUM",
+		GroupBy:         []string{"resource.label.bucket_name"},
+		AlignmentPeriod: time.Hour,
+	}, e.lookback, now)
+
+	for _, bucket := range buckets {
+		if e.excluder.Excluded(bucket.Name, bucket.Labels) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(gcsBucketInfo, prometheus.GaugeValue, 1.0, e.project, bucket.Name, strings.ToLower(bucket.Location), bucket.StorageClass)
+
+		var hasLifecyclePolicy float64
+		if gcsBucketHasLifecycleRules(bucket) {
+			hasLifecyclePolicy = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(gcsBucketHasLifecyclePolicy, prometheus.GaugeValue, hasLifecyclePolicy, e.project, bucket.Name)
+
+		if s, ok := objectCounts[bucket.Name]; ok {
+			ch <- prometheus.MustNewConstMetric(gcsBucketObjectCount, prometheus.GaugeValue, s.Value, e.project, bucket.Name)
+		}
+		if s, ok := totalBytes[bucket.Name]; ok {
+			ch <- prometheus.MustNewConstMetric(gcsBucketTotalBytes, prometheus.GaugeValue, s.Value, e.project, bucket.Name)
+		}
+
+		// Buckets without any request have no time series at all.
+		if requests != nil {
+			ch <- prometheus.MustNewConstMetric(gcsBucketRequests, prometheus.GaugeValue, requests[bucket.Name].Value, e.project, bucket.Name)
+		}
+		if seconds, ok := gcsBucketLastRequestSecondsOf(hourlyRequests, bucket.Name, e.lookback, now); ok {
+			ch <- prometheus.MustNewConstMetric(gcsBucketLastRequestSeconds, prometheus.GaugeValue, seconds, e.project, bucket.Name)
+		}
+
+		e.labelsInfo.Collect(ch, bucket.Labels, e.project, bucket.Name)
+	}
+
+	return nil
+}
+
+// queryByBucket returns the samples of a Cloud Monitoring query by bucket name,
+// or nil when the query fails.
+func (e *GCSBucketCollector) queryByBucket(ctx context.Context, what string, query MonitoringQuery, lookback time.Duration, now time.Time) map[string]MonitoringSample {
+	samples, err := QueryMonitoring(ctx, e.monitoringService, e.project, query, lookback, now, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting buckets' %s for project %s", what, e.project), "err", err)
+		return nil
+	}
+
+	byBucket := map[string]MonitoringSample{}
+	for _, s := range samples {
+		byBucket[s.ResourceLabels["bucket_name"]] = s
+	}
+	return byBucket
+}
+
+// gcsBucketMonitored tells whether a bucket belongs to the monitored regions.
+// Multi-region and dual-region buckets don't belong to a single region, so
+// they are always monitored.
+func gcsBucketMonitored(bucket *storage.Bucket, monitoredRegions []string) bool {
+	return bucket.LocationType != "region" || lo.Contains(monitoredRegions, strings.ToLower(bucket.Location))
+}
+
+// gcsBucketHasLifecycleRules tells whether a bucket has lifecycle rules deleting or moving its objects.
+func gcsBucketHasLifecycleRules(bucket *storage.Bucket) bool {
+	return bucket.Lifecycle != nil && len(bucket.Lifecycle.Rule) > 0
+}
+
+// gcsBucketLastRequestSecondsOf returns how many seconds ago a bucket last
+// received a request, according to hourly request counts. Buckets without any
+// request within the lookback window get the window as a lower bound. Nothing
+// is returned when Cloud Monitoring couldn't be queried.
+func gcsBucketLastRequestSecondsOf(hourlyRequests map[string]MonitoringSample, name string, lookback time.Duration, now time.Time) (float64, bool) {
+	if hourlyRequests == nil {
+		return 0, false
+	}
+	if s, ok := hourlyRequests[name]; ok && !s.LastNonZero.IsZero() {
+		return now.Sub(s.LastNonZero).Seconds(), true
+	}
+	return lookback.Seconds(), true
+}
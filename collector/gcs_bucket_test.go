@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/storage/v1"
+)
+
+func TestGCSBucketCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gcs_bucket collector", []string{"gcs_bucket_info", "gcs_bucket_has_lifecycle_policy", "gcs_bucket_object_count", "gcs_bucket_total_bytes", "gcs_bucket_requests", "gcs_bucket_last_request_seconds", "gcs_bucket_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := GCSBucketCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestGCSBucketMonitored(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *storage.Bucket
+		expected bool
+	}{
+		{"should monitor regional buckets of the monitored regions", &storage.Bucket{Location: "US-EAST1", LocationType: "region"}, true},
+		{"should skip regional buckets of other regions", &storage.Bucket{Location: "EUROPE-WEST1", LocationType: "region"}, false},
+		{"should monitor multi-region buckets", &storage.Bucket{Location: "US", LocationType: "multi-region"}, true},
+		{"should monitor dual-region buckets", &storage.Bucket{Location: "NAM4", LocationType: "dual-region"}, true},
+	}
+
+	for _, tc := range cases {
+		r := gcsBucketMonitored(tc.input, []string{"us-east1", "us-central1"})
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGCSBucketHasLifecycleRules(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *storage.Bucket
+		expected bool
+	}{
+		{"should tell a bucket without lifecycle", &storage.Bucket{}, false},
+		{"should tell a bucket with an empty lifecycle", &storage.Bucket{Lifecycle: &storage.BucketLifecycle{}}, false},
+		{
+			"should tell a bucket deleting old objects",
+			&storage.Bucket{Lifecycle: &storage.BucketLifecycle{Rule: []*storage.BucketLifecycleRule{
+				{Action: &storage.BucketLifecycleRuleAction{Type: "Delete"}, Condition: &storage.BucketLifecycleRuleCondition{Age: googleapi.Int64(30)}},
+			}}},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		r := gcsBucketHasLifecycleRules(tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func TestGCSBucketLastRequestSecondsOf(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	hourlyRequests := map[string]MonitoringSample{
+		"busy":     {Value: 10, LastNonZero: now.Add(-2 * time.Hour)},
+		"quieting": {Value: 0},
+	}
+
+	cases := []struct {
+		desc           string
+		hourlyRequests map[string]MonitoringSample
+		input          string
+		expected       float64
+		expectedOk     bool
+	}{
+		{"should tell when the bucket was last requested", hourlyRequests, "busy", 7200, true},
+		{"should bound buckets without non-zero point by the lookback", hourlyRequests, "quieting", 720 * 3600, true},
+		{"should bound buckets without requests by the lookback", hourlyRequests, "abandoned", 720 * 3600, true},
+		{"should tell nothing when Cloud Monitoring couldn't be queried", nil, "busy", 0, false},
+	}
+
+	for _, tc := range cases {
+		r, ok := gcsBucketLastRequestSecondsOf(tc.hourlyRequests, tc.input, 720*time.Hour, now)
+		if r != tc.expected || ok != tc.expectedOk {
+			t.Errorf("%s want %v, %v got %v, %v instead", tc.desc, tc.expected, tc.expectedOk, r, ok)
+		}
+	}
+}
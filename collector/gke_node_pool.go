@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/container/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	gkeNodePoolNodes          = prometheus.NewDesc("gke_node_pool_nodes", "tells how many nodes the GKE node pool is meant to run", []string{"project", "location", "cluster", "name"}, nil)
+	gkeNodePoolMinNodes       = prometheus.NewDesc("gke_node_pool_autoscaling_min_nodes", "tells the minimum number of nodes the autoscaler keeps in the GKE node pool across all its zones", []string{"project", "location", "cluster", "name"}, nil)
+	gkeNodePoolMaxNodes       = prometheus.NewDesc("gke_node_pool_autoscaling_max_nodes", "tells the maximum number of nodes the autoscaler runs in the GKE node pool across all its zones", []string{"project", "location", "cluster", "name"}, nil)
+	gkeNodePoolCanScaleToZero = prometheus.NewDesc("gke_node_pool_can_scale_to_zero", "tells whether the autoscaler can remove every node of the GKE node pool", []string{"project", "location", "cluster", "name"}, nil)
+	gkeNodePoolInfo           = prometheus.NewDesc("gke_node_pool_info", "tells the machine type and status of the GKE node pool", []string{"project", "location", "cluster", "name", "machine_type", "status"}, nil)
+)
+
+type GKENodePoolCollector struct {
+	logger           log.Logger
+	service          *container.Service
+	computeService   *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	labelsInfo       *ResourceLabelsInfo
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("gke_node_pool", defaultDisabled, NewGKENodePoolCollector)
+}
+
+func (e *GKENodePoolCollector) ListMetrics() []string {
+	return []string{"gke_node_pool_nodes", "gke_node_pool_autoscaling_min_nodes", "gke_node_pool_autoscaling_max_nodes", "gke_node_pool_can_scale_to_zero", "gke_node_pool_info", "gke_cluster_labels"}
+}
+
+func NewGKENodePoolCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("gke_node_pool", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, container.CloudPlatformScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	containerService, err := container.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &GKENodePoolCollector{
+		logger:           logger,
+		service:          containerService,
+		computeService:   computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("gke_node_pool", project),
+		excluder:         excluder,
+		labelsInfo:       NewResourceLabelsInfo("gke_cluster_labels", "GCP labels of the GKE cluster", []string{"project", "location", "cluster"}),
+	}, nil
+}
+
+func (e *GKENodePoolCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+
+	// Clusters of every location are listed at once, the API doesn't paginate them.
+	clusters, err := e.service.Projects.Locations.Clusters.List(fmt.Sprintf("projects/%s/locations/-", e.project)).Context(ctx).Do()
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting GKE clusters for project %s", e.project), "err", err)
+		return err
+	}
+	e.pagesFetched.Inc()
+
+	if len(clusters.MissingZones) > 0 {
+		level.Warn(e.logger).Log("msg", fmt.Sprintf("GKE clusters of project %s couldn't be listed in some zones", e.project), "zones", fmt.Sprint(clusters.MissingZones))
+	}
+
+	var wgClusters sync.WaitGroup
+	for _, cluster := range clusters.Clusters {
+		if !lo.Contains(e.monitoredRegions, GetGCPRegionFromLocation(cluster.Location)) {
+			continue
+		}
+		if e.excluder.Excluded(cluster.Name, cluster.ResourceLabels) {
+			continue
+		}
+
+		e.labelsInfo.Collect(ch, cluster.ResourceLabels, e.project, cluster.Location, cluster.Name)
+
+		for _, pool := range cluster.NodePools {
+			wgClusters.Add(1)
+			go func(cluster *container.Cluster, pool *container.NodePool) {
+				defer wgClusters.Done()
+				e.reportNodePool(ctx, ch, cluster, pool)
+			}(cluster, pool)
+		}
+	}
+	wgClusters.Wait()
+
+	return nil
+}
+
+func (e *GKENodePoolCollector) reportNodePool(ctx context.Context, ch chan<- prometheus.Metric, cluster *container.Cluster, pool *container.NodePool) {
+	labels := []string{e.project, cluster.Location, cluster.Name, pool.Name}
+
+	var machineType string
+	if pool.Config != nil {
+		machineType = pool.Config.MachineType
+	}
+	ch <- prometheus.MustNewConstMetric(gkeNodePoolInfo, prometheus.GaugeValue, 1., append(labels, machineType, pool.Status)...)
+
+	nodes, err := e.countNodes(ctx, pool)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting the instance groups of GKE node pool %s of cluster %s for project %s", pool.Name, cluster.Name, e.project), "err", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(gkeNodePoolNodes, prometheus.GaugeValue, float64(nodes), labels...)
+	}
+
+	if pool.Autoscaling == nil || !pool.Autoscaling.Enabled {
+		ch <- prometheus.MustNewConstMetric(gkeNodePoolCanScaleToZero, prometheus.GaugeValue, 0., labels...)
+		return
+	}
+
+	minNodes, maxNodes := nodePoolAutoscalingLimits(pool)
+	ch <- prometheus.MustNewConstMetric(gkeNodePoolMinNodes, prometheus.GaugeValue, float64(minNodes), labels...)
+	ch <- prometheus.MustNewConstMetric(gkeNodePoolMaxNodes, prometheus.GaugeValue, float64(maxNodes), labels...)
+
+	var canScaleToZero float64
+	if minNodes == 0 {
+		canScaleToZero = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(gkeNodePoolCanScaleToZero, prometheus.GaugeValue, canScaleToZero, labels...)
+}
+
+// nodePoolAutoscalingLimits returns the autoscaling limits of the whole node
+// pool, which are set either in total or per zone.
+func nodePoolAutoscalingLimits(pool *container.NodePool) (int64, int64) {
+	a := pool.Autoscaling
+	if a.TotalMinNodeCount > 0 || a.TotalMaxNodeCount > 0 {
+		return a.TotalMinNodeCount, a.TotalMaxNodeCount
+	}
+
+	zones := int64(len(pool.Locations))
+	if zones == 0 {
+		zones = 1
+	}
+	return a.MinNodeCount * zones, a.MaxNodeCount * zones
+}
+
+// countNodes sums the target sizes of the node pool's managed instance groups, one per zone.
+func (e *GKENodePoolCollector) countNodes(ctx context.Context, pool *container.NodePool) (int64, error) {
+	var nodes int64
+	for _, u := range pool.InstanceGroupUrls {
+		igm, err := e.computeService.InstanceGroupManagers.Get(e.project, GetGCPZoneFromURL(e.logger, u), GetResourceNameFromURL(e.logger, u)).Context(ctx).Do()
+		e.pagesFetched.Inc()
+		if err != nil {
+			return 0, err
+		}
+		nodes += igm.TargetSize
+	}
+
+	return nodes, nil
+}
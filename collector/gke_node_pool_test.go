@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/container/v1"
+)
+
+func TestGKENodePoolCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for gke_node_pool collector", []string{"gke_node_pool_nodes", "gke_node_pool_autoscaling_min_nodes", "gke_node_pool_autoscaling_max_nodes", "gke_node_pool_can_scale_to_zero", "gke_node_pool_info", "gke_cluster_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := GKENodePoolCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestNodePoolAutoscalingLimits(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *container.NodePool
+		expected []int64
+	}{
+		{
+			"should multiply per zone limits by the number of zones",
+			&container.NodePool{
+				Locations:   []string{"us-east1-b", "us-east1-c", "us-east1-d"},
+				Autoscaling: &container.NodePoolAutoscaling{Enabled: true, MinNodeCount: 1, MaxNodeCount: 5},
+			},
+			[]int64{3, 15},
+		},
+		{
+			"should return total limits",
+			&container.NodePool{
+				Locations:   []string{"us-east1-b", "us-east1-c"},
+				Autoscaling: &container.NodePoolAutoscaling{Enabled: true, TotalMaxNodeCount: 10},
+			},
+			[]int64{0, 10},
+		},
+	}
+
+	for _, tc := range cases {
+		minNodes, maxNodes := nodePoolAutoscalingLimits(tc.input)
+		if r := []int64{minNodes, maxNodes}; !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
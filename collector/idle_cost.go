@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const bytesPerGB = 1 << 30
+
+var (
+	idleResourceEstimatedMonthlyCost = prometheus.NewDesc("gcp_idle_resource_estimated_monthly_cost", "tells how many USD the idle resource is estimated to cost per month", []string{"project", "location", "resource_type", "name"}, nil)
+)
+
+// Pricer estimates the cost of idle resources. No cost is reported when it is nil.
+var Pricer pricing.Pricer
+
+// collectIdleResourceCost sends the estimated monthly cost of an idle resource
+// as computed by estimate with the configured Pricer.
+func collectIdleResourceCost(ch chan<- prometheus.Metric, logger log.Logger, estimate func(p pricing.Pricer) (float64, error), project string, location string, resourceType string, name string) {
+	if Pricer == nil {
+		return
+	}
+
+	cost, err := estimate(Pricer)
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't estimate idle resource cost", "resource_type", resourceType, "name", name, "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		idleResourceEstimatedMonthlyCost,
+		prometheus.GaugeValue,
+		cost,
+		project,
+		location,
+		resourceType,
+		name)
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type fakePricer struct{}
+
+func (f fakePricer) DiskGBMonth(region string, diskType string) (float64, error) {
+	if diskType != "pd-standard" {
+		return 0, pricing.ErrNoPrice
+	}
+	return 0.04, nil
+}
+
+func (f fakePricer) SnapshotGBMonth(location string) (float64, error) {
+	return 0.05, nil
+}
+
+func (f fakePricer) StaticIPMonth(region string) (float64, error) {
+	return 7.3, nil
+}
+
+func TestCollectIdleResourceCost(t *testing.T) {
+	cases := []struct {
+		desc     string
+		pricer   pricing.Pricer
+		estimate func(p pricing.Pricer) (float64, error)
+		expected []float64
+	}{
+		{
+			"should report nothing without pricer",
+			nil,
+			func(p pricing.Pricer) (float64, error) { return 1, nil },
+			[]float64{},
+		},
+		{
+			"should report nothing without price",
+			fakePricer{},
+			func(p pricing.Pricer) (float64, error) { return p.DiskGBMonth("us-east1", "pd-ssd") },
+			[]float64{},
+		},
+		{
+			"should report the estimated cost",
+			fakePricer{},
+			func(p pricing.Pricer) (float64, error) {
+				price, err := p.DiskGBMonth("us-east1", "pd-standard")
+				return price * 100, err
+			},
+			[]float64{4},
+		},
+	}
+
+	defer func(p pricing.Pricer) { Pricer = p }(Pricer)
+	for _, tc := range cases {
+		Pricer = tc.pricer
+
+		ch := make(chan prometheus.Metric, 1)
+		collectIdleResourceCost(ch, log.NewJSONLogger(os.Stdout), tc.estimate, "project", "us-east1-b", "disk", "disk")
+		close(ch)
+
+		r := []float64{}
+		for m := range ch {
+			pb := &dto.Metric{}
+			if err := m.Write(pb); err != nil {
+				t.Fatal(err)
+			}
+			r = append(r, pb.GetGauge().GetValue())
+		}
+		if len(r) != len(tc.expected) || (len(r) > 0 && r[0] != tc.expected[0]) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/monitoring/v3"
+)
+
+// MonitoringQuery describes how to aggregate a Cloud Monitoring metric over a
+// lookback window.
+type MonitoringQuery struct {
+	// Filter selects the time series, e.g. metric.type="..." AND resource.type="...".
+	Filter string
+	// Aligner aggregates each time series over the window, e.g. ALIGN_MEAN or ALIGN_RATE.
+	Aligner string
+	// Reducer, e.g. REDUCE_SUM, combines the time series sharing the GroupBy
+	// fields. No reduction happens when it is empty.
+	Reducer string
+	GroupBy []string
+	// AlignmentPeriod splits the window into several points. It defaults to
+	// the whole window.
+	AlignmentPeriod time.Duration
+}
+
+// MonitoringSample is the value of a time series aggregated over the lookback window.
+type MonitoringSample struct {
+	ResourceLabels map[string]string
+	MetricLabels   map[string]string
+	// Value is the value of the newest point.
+	Value float64
+	// LastNonZero is the end of the newest point whose value isn't zero, if any.
+	LastNonZero time.Time
+}
+
+// QueryMonitoring aggregates the time series selected by query over the
+// lookback window ending at now, giving a single sample per time series.
+func QueryMonitoring(ctx context.Context, service *monitoring.Service, project string, query MonitoringQuery, lookback time.Duration, now time.Time, pagesFetched prometheus.Counter) ([]MonitoringSample, error) {
+	alignmentPeriod := query.AlignmentPeriod
+	if alignmentPeriod == 0 {
+		alignmentPeriod = lookback
+	}
+
+	call := service.Projects.TimeSeries.List(fmt.Sprintf("projects/%s", project)).
+		Filter(query.Filter).
+		IntervalStartTime(now.Add(-lookback).UTC().Format(time.RFC3339)).
+		IntervalEndTime(now.UTC().Format(time.RFC3339)).
+		AggregationAlignmentPeriod(fmt.Sprintf("%ds", int64(alignmentPeriod.Seconds()))).
+		AggregationPerSeriesAligner(query.Aligner)
+	if query.Reducer != "" {
+		call = call.AggregationCrossSeriesReducer(query.Reducer).AggregationGroupByFields(query.GroupBy...)
+	}
+
+	samples := []MonitoringSample{}
+	err := call.Pages(ctx, func(page *monitoring.ListTimeSeriesResponse) error {
+		pagesFetched.Inc()
+		for _, ts := range page.TimeSeries {
+			// Points come newest first.
+			if len(ts.Points) == 0 {
+				continue
+			}
+			value, ok := monitoringPointValue(ts.Points[0])
+			if !ok {
+				continue
+			}
+
+			sample := MonitoringSample{Value: value}
+			for _, p := range ts.Points {
+				if v, ok := monitoringPointValue(p); ok && v != 0 && p.Interval != nil {
+					if end, err := time.Parse(time.RFC3339, p.Interval.EndTime); err == nil {
+						sample.LastNonZero = end
+						break
+					}
+				}
+			}
+			if ts.Resource != nil {
+				sample.ResourceLabels = ts.Resource.Labels
+			}
+			if ts.Metric != nil {
+				sample.MetricLabels = ts.Metric.Labels
+			}
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+func monitoringPointValue(p *monitoring.Point) (float64, bool) {
+	switch v := p.Value; {
+	case v == nil:
+		return 0, false
+	case v.DoubleValue != nil:
+		return *v.DoubleValue, true
+	case v.Int64Value != nil:
+		return float64(*v.Int64Value), true
+	default:
+		return 0, false
+	}
+}
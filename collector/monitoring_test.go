@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+)
+
+func TestQueryMonitoring(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v3/projects/project/timeSeries" ||
+			q.Get("interval.startTime") != "2023-03-31T12:00:00Z" ||
+			q.Get("interval.endTime") != "2023-04-01T12:00:00Z" ||
+			q.Get("aggregation.alignmentPeriod") != "86400s" ||
+			q.Get("aggregation.crossSeriesReducer") != "REDUCE_SUM" ||
+			q.Get("aggregation.groupByFields") != "resource.label.instance_id" {
+			http.Error(w, "unexpected query "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"timeSeries": [
+			{"resource": {"labels": {"instance_id": "1"}}, "points": [
+				{"interval": {"endTime": "2023-04-01T12:00:00Z"}, "value": {"doubleValue": 0}},
+				{"interval": {"endTime": "2023-04-01T11:00:00Z"}, "value": {"doubleValue": 0.9}}
+			]},
+			{"resource": {"labels": {"instance_id": "2"}}, "points": [{"value": {"int64Value": "42"}}]},
+			{"resource": {"labels": {"instance_id": "3"}}, "points": []}
+		]}`))
+	}))
+	defer srv.Close()
+
+	service, err := monitoring.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := QueryMonitoring(context.Background(), service, "project", MonitoringQuery{
+		Filter:  `metric.type="compute.googleapis.com/instance/network/received_bytes_count"`,
+		Aligner: "ALIGN_RATE",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.instance_id"},
+	}, 24*time.Hour, now, prometheus.NewCounter(prometheus.CounterOpts{Name: "pages"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []MonitoringSample{
+		{ResourceLabels: map[string]string{"instance_id": "1"}, Value: 0, LastNonZero: time.Date(2023, 4, 1, 11, 0, 0, 0, time.UTC)},
+		{ResourceLabels: map[string]string{"instance_id": "2"}, Value: 42},
+	}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("expected %+v got %+v", expected, samples)
+	}
+}
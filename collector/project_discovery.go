@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/api/option"
+)
+
+// ProjectDiscoverer lists the ACTIVE projects found under a set of folders and
+// organizations, including nested folders, and keeps that list up to date.
+type ProjectDiscoverer struct {
+	logger  log.Logger
+	service *cloudresourcemanager.Service
+	parents []string
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+
+	mutex    sync.RWMutex
+	projects []string
+}
+
+// NewProjectDiscoverer creates a ProjectDiscoverer for the given parents, e.g.
+// "folders/123" or "organizations/456". Project IDs must fully match include,
+// when set, and must not fully match exclude, when set.
+func NewProjectDiscoverer(logger log.Logger, parents []string, include string, exclude string) (*ProjectDiscoverer, error) {
+	d := &ProjectDiscoverer{
+		logger:  logger,
+		parents: parents,
+	}
+
+	var err error
+	if include != "" {
+		if d.include, err = regexp.Compile("^(?:" + include + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid project include regex: %w", err)
+		}
+	}
+	if exclude != "" {
+		if d.exclude, err = regexp.Compile("^(?:" + exclude + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid project exclude regex: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, cloudresourcemanager.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return nil, err
+	}
+
+	d.service, err = cloudresourcemanager.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		return nil, fmt.Errorf("error creating Resource Manager service: %+v", err)
+	}
+
+	return d, nil
+}
+
+// Projects returns the project IDs found by the last successful refresh.
+func (d *ProjectDiscoverer) Projects() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.projects
+}
+
+// Run refreshes the project list on every interval until ctx is done.
+func (d *ProjectDiscoverer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				level.Error(d.logger).Log("msg", "project discovery failed, keeping previous project list", "err", err)
+			}
+		}
+	}
+}
+
+// Refresh walks every parent and replaces the project list once all of them
+// have been listed successfully.
+func (d *ProjectDiscoverer) Refresh(ctx context.Context) error {
+	found := []string{}
+	for _, parent := range d.parents {
+		projects, err := d.listProjects(ctx, parent)
+		if err != nil {
+			return err
+		}
+		found = append(found, projects...)
+	}
+
+	projects := filterProjects(found, d.include, d.exclude)
+
+	d.mutex.Lock()
+	d.projects = projects
+	d.mutex.Unlock()
+
+	level.Info(d.logger).Log("msg", fmt.Sprintf("discovered %d projects", len(projects)), "projects", fmt.Sprintf("%v", projects))
+	return nil
+}
+
+func (d *ProjectDiscoverer) listProjects(ctx context.Context, parent string) ([]string, error) {
+	projects := []string{}
+	err := d.service.Projects.List().Parent(parent).Pages(ctx, func(page *cloudresourcemanager.ListProjectsResponse) error {
+		for _, p := range page.Projects {
+			if p.State == "ACTIVE" {
+				projects = append(projects, p.ProjectId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing projects under %s: %w", parent, err)
+	}
+
+	folders := []string{}
+	err = d.service.Folders.List().Parent(parent).Pages(ctx, func(page *cloudresourcemanager.ListFoldersResponse) error {
+		for _, f := range page.Folders {
+			if f.State == "ACTIVE" {
+				folders = append(folders, f.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing folders under %s: %w", parent, err)
+	}
+
+	for _, folder := range folders {
+		folderProjects, err := d.listProjects(ctx, folder)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, folderProjects...)
+	}
+
+	return projects, nil
+}
+
+// filterProjects returns the sorted, deduplicated project IDs which match
+// include and don't match exclude. Nil regexes are ignored.
+func filterProjects(projects []string, include *regexp.Regexp, exclude *regexp.Regexp) []string {
+	seen := map[string]bool{}
+	filtered := []string{}
+	for _, p := range projects {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		if include != nil && !include.MatchString(p) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(p) {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+
+	sort.Strings(filtered)
+	return filtered
+}
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/go-kit/log"
+	"google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/api/option"
+)
+
+// fakeResourceManagerPages holds the projects and folders listed under each
+// parent, by page token.
+var fakeResourceManagerPages = map[string]map[string]string{
+	"/v3/projects?parent=organizations/1": {
+		"": `{"projects": [
+			{"projectId": "org-project", "state": "ACTIVE"},
+			{"projectId": "deleted-project", "state": "DELETE_REQUESTED"}
+		], "nextPageToken": "page2"}`,
+		"page2": `{"projects": [{"projectId": "org-project-page2", "state": "ACTIVE"}]}`,
+	},
+	"/v3/folders?parent=organizations/1": {
+		"": `{"folders": [
+			{"name": "folders/10", "state": "ACTIVE"},
+			{"name": "folders/11", "state": "DELETE_REQUESTED"}
+		]}`,
+	},
+	"/v3/projects?parent=folders/10": {
+		"": `{"projects": [{"projectId": "folder-project", "state": "ACTIVE"}]}`,
+	},
+	"/v3/folders?parent=folders/10": {
+		"": `{"folders": [{"name": "folders/20", "state": "ACTIVE"}]}`,
+	},
+	"/v3/projects?parent=folders/20": {
+		"": `{"projects": [{"projectId": "nested-project", "state": "ACTIVE"}]}`,
+	},
+	"/v3/folders?parent=folders/20": {
+		"": `{}`,
+	},
+	"/v3/projects?parent=folders/11": {
+		"": `{"projects": [{"projectId": "deleted-folder-project", "state": "ACTIVE"}]}`,
+	},
+	"/v3/folders?parent=folders/11": {
+		"": `{}`,
+	},
+}
+
+func newFakeResourceManager(t *testing.T) *cloudresourcemanager.Service {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := fakeResourceManagerPages[r.URL.Path+"?parent="+r.URL.Query().Get("parent")][r.URL.Query().Get("pageToken")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	service, err := cloudresourcemanager.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func TestProjectDiscovererRefresh(t *testing.T) {
+	service := newFakeResourceManager(t)
+	cases := []struct {
+		desc     string
+		parents  []string
+		err      bool
+		expected []string
+	}{
+		{
+			"should list active projects of every page and nested active folder",
+			[]string{"organizations/1"},
+			false,
+			[]string{"folder-project", "nested-project", "org-project", "org-project-page2"},
+		},
+		{
+			"should list projects of a folder",
+			[]string{"folders/20"},
+			false,
+			[]string{"nested-project"},
+		},
+		{
+			"should keep the previous projects when a parent can't be listed",
+			[]string{"folders/10", "organizations/2"},
+			true,
+			[]string{"previous-project"},
+		},
+	}
+
+	for _, tc := range cases {
+		d := &ProjectDiscoverer{
+			logger:   log.NewJSONLogger(os.Stdout),
+			service:  service,
+			parents:  tc.parents,
+			projects: []string{"previous-project"},
+		}
+		err := d.Refresh(context.Background())
+		if (err != nil) != tc.err || !reflect.DeepEqual(d.Projects(), tc.expected) {
+			t.Errorf("%s want error %v and %v got %v and %v instead", tc.desc, tc.err, tc.expected, err, d.Projects())
+		}
+	}
+}
+
+func TestFilterProjects(t *testing.T) {
+	cases := []struct {
+		desc     string
+		include  *regexp.Regexp
+		exclude  *regexp.Regexp
+		expected []string
+	}{
+		{"should keep every project sorted and deduplicated", nil, nil, []string{"data-prod", "data-staging", "web-prod"}},
+		{"should keep only included projects", regexp.MustCompile("^(?:data-.*)$"), nil, []string{"data-prod", "data-staging"}},
+		{"should drop excluded projects", nil, regexp.MustCompile("^(?:.*-staging)$"), []string{"data-prod", "web-prod"}},
+		{"should apply exclude after include", regexp.MustCompile("^(?:data-.*)$"), regexp.MustCompile("^(?:.*-staging)$"), []string{"data-prod"}},
+	}
+
+	for _, tc := range cases {
+		r := filterProjects([]string{"web-prod", "data-staging", "data-prod", "web-prod"}, tc.include, tc.exclude)
+		if !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/7onn/gcp-idleness-exporter/pricing"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/api/recommender/v1"
+)
+
+var (
+	idleResourceRecommendation             = prometheus.NewDesc("recommender_idle_resource_recommendation", "tells which resources the GCP Recommender considers idle", []string{"project", "location", "resource_type", "name", "state", "priority"}, nil)
+	idleResourceRecommendationSavings      = prometheus.NewDesc("recommender_idle_resource_projected_monthly_savings", "tells how much the GCP Recommender projects to save per month by deleting the idle resource", []string{"project", "location", "resource_type", "name", "currency"}, nil)
+	idleResourceRecommendationRecommenders = []idleResourceRecommender{
+		{"google.compute.instance.IdleResourceRecommender", "instance", []string{"zonal"}},
+		{"google.compute.disk.IdleResourceRecommender", "disk", []string{"zonal", "regional"}},
+		{"google.compute.address.IdleResourceRecommender", "address", []string{"regional", "global"}},
+		{"google.compute.image.IdleResourceRecommender", "image", []string{"global"}},
+	}
+)
+
+// idleResourceRecommender is a Recommender which spots idle resources of a
+// type, along with the kinds of locations its recommendations live in.
+type idleResourceRecommender struct {
+	id           string
+	resourceType string
+	scopes       []string
+}
+
+type RecommenderIdleResourcesCollector struct {
+	logger           log.Logger
+	service          *recommender.Service
+	computeService   *compute.Service
+	project          string
+	monitoredRegions []string
+	pagesFetched     prometheus.Counter
+	excluder         *ResourceExcluder
+	mutex            sync.RWMutex
+}
+
+func init() {
+	registerCollector("recommender_idle_resources", defaultDisabled, NewRecommenderIdleResourcesCollector)
+}
+
+func (e *RecommenderIdleResourcesCollector) ListMetrics() []string {
+	return []string{"recommender_idle_resource_recommendation", "recommender_idle_resource_projected_monthly_savings"}
+}
+
+func NewRecommenderIdleResourcesCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("recommender_idle_resources", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, recommender.CloudPlatformScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	recommenderService, err := recommender.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	computeService, err := compute.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &RecommenderIdleResourcesCollector{
+		logger:           logger,
+		service:          recommenderService,
+		computeService:   computeService,
+		project:          project,
+		monitoredRegions: monitoredRegions,
+		pagesFetched:     apiPagesFetched.WithLabelValues("recommender_idle_resources", project),
+		excluder:         excluder,
+	}, nil
+}
+
+func (e *RecommenderIdleResourcesCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	zones, err := ListMonitoredZones(ctx, e.logger, e.computeService, e.project, e.monitoredRegions, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("Failure when querying %s regions", e.project), "err", err)
+		return err
+	}
+
+	locations := map[string][]string{
+		"zonal":    zones,
+		"regional": e.monitoredRegions,
+		"global":   {"global"},
+	}
+
+	var wgLocations sync.WaitGroup
+	for _, r := range idleResourceRecommendationRecommenders {
+		for _, scope := range r.scopes {
+			for _, location := range locations[scope] {
+				wgLocations.Add(1)
+				go func(r idleResourceRecommender, location string) {
+					defer wgLocations.Done()
+					e.collectRecommendations(ctx, ch, r, location)
+				}(r, location)
+			}
+		}
+	}
+	wgLocations.Wait()
+
+	return nil
+}
+
+func (e *RecommenderIdleResourcesCollector) collectRecommendations(ctx context.Context, ch chan<- prometheus.Metric, r idleResourceRecommender, location string) {
+	parent := fmt.Sprintf("projects/%s/locations/%s/recommenders/%s", e.project, location, r.id)
+	// Dismissed and succeeded recommendations are history, which may hold
+	// several recommendations about the same resource.
+	call := e.service.Projects.Locations.Recommenders.Recommendations.List(parent).Filter("stateInfo.state=ACTIVE")
+	err := call.Pages(ctx, func(page *recommender.GoogleCloudRecommenderV1ListRecommendationsResponse) error {
+		e.pagesFetched.Inc()
+		for _, recommendation := range page.Recommendations {
+			// Recommendations carry the resource's URL only, not its labels.
+			name := GetResourceNameFromURL(e.logger, recommendationResource(recommendation))
+			if name == "" || e.excluder.Excluded(name, nil) {
+				continue
+			}
+
+			var state string
+			if recommendation.StateInfo != nil {
+				state = recommendation.StateInfo.State
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				idleResourceRecommendation,
+				prometheus.GaugeValue,
+				1.0,
+				e.project,
+				location,
+				r.resourceType,
+				name,
+				state,
+				recommendation.Priority)
+
+			savings, currency, err := monthlySavings(recommendation.PrimaryImpact)
+			if err != nil {
+				level.Error(e.logger).Log("msg", fmt.Sprintf("error parsing the projected savings of recommendation %s for project %s", recommendation.Name, e.project), "err", err)
+				continue
+			}
+			if currency == "" {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				idleResourceRecommendationSavings,
+				prometheus.GaugeValue,
+				savings,
+				e.project,
+				location,
+				r.resourceType,
+				name,
+				currency)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting %s recommendations for project %s in %s", r.resourceType, e.project, location), "err", err)
+	}
+}
+
+// recommendationResource returns the URL of the resource a recommendation is
+// about, i.e. the one it removes or stops. Other operations, e.g. snapshotting
+// an idle disk first, are about other resources.
+func recommendationResource(r *recommender.GoogleCloudRecommenderV1Recommendation) string {
+	if r.Content == nil {
+		return ""
+	}
+	for _, group := range r.Content.OperationGroups {
+		for _, operation := range group.Operations {
+			if operation.Action == "remove" || operation.Action == "stop" {
+				return operation.Resource
+			}
+		}
+	}
+	return ""
+}
+
+// monthlySavings converts the cost projection of a recommendation, which is
+// negative when money is saved, into monthly savings. The currency is empty
+// when the recommendation projects no cost.
+func monthlySavings(impact *recommender.GoogleCloudRecommenderV1Impact) (float64, string, error) {
+	if impact == nil || impact.CostProjection == nil || impact.CostProjection.Cost == nil {
+		return 0, "", nil
+	}
+
+	duration, err := time.ParseDuration(impact.CostProjection.Duration)
+	if err != nil {
+		return 0, "", err
+	}
+	if duration <= 0 {
+		return 0, "", fmt.Errorf("invalid cost projection duration %q", impact.CostProjection.Duration)
+	}
+
+	cost := impact.CostProjection.Cost
+	amount := float64(cost.Units) + float64(cost.Nanos)/1e9
+	return -amount * pricing.HoursPerMonth / duration.Hours(), cost.CurrencyCode, nil
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/recommender/v1"
+)
+
+func TestRecommenderIdleResourcesCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for recommender_idle_resources collector", []string{"recommender_idle_resource_recommendation", "recommender_idle_resource_projected_monthly_savings"}},
+	}
+
+	for _, tc := range cases {
+		collector := RecommenderIdleResourcesCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestMonthlySavings(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *recommender.GoogleCloudRecommenderV1Impact
+		expected float64
+		currency string
+		err      bool
+	}{
+		{
+			"should have no savings without cost projection",
+			&recommender.GoogleCloudRecommenderV1Impact{Category: "COST"},
+			0, "", false,
+		},
+		{
+			"should convert the 30 days projection into monthly savings",
+			&recommender.GoogleCloudRecommenderV1Impact{CostProjection: &recommender.GoogleCloudRecommenderV1CostProjection{
+				Cost:     &recommender.GoogleTypeMoney{CurrencyCode: "USD", Units: -7, Nanos: -200000000},
+				Duration: "2628000s",
+			}},
+			7.2, "USD", false,
+		},
+		{
+			"should fail on invalid durations",
+			&recommender.GoogleCloudRecommenderV1Impact{CostProjection: &recommender.GoogleCloudRecommenderV1CostProjection{
+				Cost:     &recommender.GoogleTypeMoney{CurrencyCode: "USD", Units: -7},
+				Duration: "0s",
+			}},
+			0, "", true,
+		},
+	}
+
+	for _, tc := range cases {
+		r, currency, err := monthlySavings(tc.input)
+		if (err != nil) != tc.err {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if math.Abs(r-tc.expected) > 1e-9 || currency != tc.currency {
+			t.Errorf("%s want %v %s got %v %s instead", tc.desc, tc.expected, tc.currency, r, currency)
+		}
+	}
+}
+
+func TestRecommendationResource(t *testing.T) {
+	disk := "//compute.googleapis.com/projects/p/zones/us-east1-b/disks/idle-disk"
+	cases := []struct {
+		desc     string
+		input    *recommender.GoogleCloudRecommenderV1Recommendation
+		expected string
+	}{
+		{"should return nothing without content", &recommender.GoogleCloudRecommenderV1Recommendation{}, ""},
+		{
+			"should return the disk rather than its snapshot",
+			&recommender.GoogleCloudRecommenderV1Recommendation{Content: &recommender.GoogleCloudRecommenderV1RecommendationContent{
+				OperationGroups: []*recommender.GoogleCloudRecommenderV1OperationGroup{{Operations: []*recommender.GoogleCloudRecommenderV1Operation{
+					{Action: "add", Resource: "//compute.googleapis.com/projects/p/global/snapshots/$snapshot-name"},
+					{Action: "remove", Resource: disk},
+				}}},
+			}},
+			disk,
+		},
+		{
+			"should return the stopped instance",
+			&recommender.GoogleCloudRecommenderV1Recommendation{Content: &recommender.GoogleCloudRecommenderV1RecommendationContent{
+				OperationGroups: []*recommender.GoogleCloudRecommenderV1OperationGroup{{Operations: []*recommender.GoogleCloudRecommenderV1Operation{
+					{Action: "test", Resource: "//compute.googleapis.com/projects/p/zones/us-east1-b/instances/vm"},
+					{Action: "stop", Resource: "//compute.googleapis.com/projects/p/zones/us-east1-b/instances/vm"},
+				}}},
+			}},
+			"//compute.googleapis.com/projects/p/zones/us-east1-b/instances/vm",
+		},
+		{
+			"should return nothing without removal nor stop",
+			&recommender.GoogleCloudRecommenderV1Recommendation{Content: &recommender.GoogleCloudRecommenderV1RecommendationContent{
+				OperationGroups: []*recommender.GoogleCloudRecommenderV1OperationGroup{{Operations: []*recommender.GoogleCloudRecommenderV1Operation{
+					{Action: "add", Resource: "//compute.googleapis.com/projects/p/global/snapshots/$snapshot-name"},
+				}}},
+			}},
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		r := recommendationResource(tc.input)
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ResourceLabels is the allow-list of GCP resource labels copied onto the
+// *_labels info metrics, following the kube-state-metrics pattern.
+var ResourceLabels []string
+
+// ResourceLabelsInfo exports the allowed GCP resource labels of a resource as
+// label_<name> labels of an info metric.
+type ResourceLabelsInfo struct {
+	desc *prometheus.Desc
+	keys []string
+}
+
+// NewResourceLabelsInfo creates a ResourceLabelsInfo for the current allow-list.
+// The info metric carries variableLabels followed by the resource labels.
+func NewResourceLabelsInfo(name string, help string, variableLabels []string) *ResourceLabelsInfo {
+	keys, names := allowedResourceLabels()
+
+	return &ResourceLabelsInfo{
+		desc: prometheus.NewDesc(name, help, append(append([]string{}, variableLabels...), names...), nil),
+		keys: keys,
+	}
+}
+
+// Collect sends the info metric of a resource, unless no resource label is allowed.
+func (i *ResourceLabelsInfo) Collect(ch chan<- prometheus.Metric, labels map[string]string, labelValues ...string) {
+	if i == nil || len(i.keys) == 0 {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(i.desc, prometheus.GaugeValue, 1, append(labelValues, i.values(labels)...)...)
+}
+
+func (i *ResourceLabelsInfo) values(labels map[string]string) []string {
+	values := make([]string, 0, len(i.keys))
+	for _, k := range i.keys {
+		values = append(values, labels[k])
+	}
+	return values
+}
+
+// allowedResourceLabels returns the GCP label keys of the allow-list along with
+// their Prometheus label names, dropping keys which would share a name.
+func allowedResourceLabels() ([]string, []string) {
+	keys := []string{}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, k := range ResourceLabels {
+		name := "label_" + invalidLabelCharRE.ReplaceAllString(k, "_")
+		if k == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		keys = append(keys, k)
+		names = append(names, name)
+	}
+	return keys, names
+}
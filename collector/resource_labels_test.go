@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourceLabelsInfoValues(t *testing.T) {
+	cases := []struct {
+		desc           string
+		resourceLabels []string
+		labels         map[string]string
+		expectedNames  []string
+		expected       []string
+	}{
+		{
+			"should return no values without allow-list",
+			nil,
+			map[string]string{"team": "data"},
+			[]string{},
+			[]string{},
+		},
+		{
+			"should return allowed values in order, empty when missing",
+			[]string{"team", "cost-center"},
+			map[string]string{"team": "data", "env": "prod"},
+			[]string{"label_team", "label_cost_center"},
+			[]string{"data", ""},
+		},
+		{
+			"should drop labels sharing a sanitized name",
+			[]string{"cost-center", "cost_center"},
+			map[string]string{"cost-center": "1", "cost_center": "2"},
+			[]string{"label_cost_center"},
+			[]string{"1"},
+		},
+	}
+
+	defer func(r []string) { ResourceLabels = r }(ResourceLabels)
+	for _, tc := range cases {
+		ResourceLabels = tc.resourceLabels
+		if _, names := allowedResourceLabels(); !reflect.DeepEqual(names, tc.expectedNames) {
+			t.Errorf("%s want names %v got %v instead", tc.desc, tc.expectedNames, names)
+		}
+		info := NewResourceLabelsInfo("test_labels", "test", []string{"name"})
+		if r := info.values(tc.labels); !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	cloudfunctions "google.golang.org/api/cloudfunctions/v2"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+	run "google.golang.org/api/run/v2"
+)
+
+var (
+	serverlessLookback = kingpin.Flag(
+		"collector.serverless.lookback",
+		"Window over which requests to Cloud Run services and Cloud Functions are counted.",
+	).Default("168h").Duration()
+
+	serverlessMinInstances       = prometheus.NewDesc("serverless_min_instances", "tells how many instances the Cloud Run service or Cloud Function keeps warm", []string{"project", "region", "name", "resource_type"}, nil)
+	serverlessCPUAlwaysAllocated = prometheus.NewDesc("serverless_cpu_always_allocated", "tells whether CPU is allocated to the instances outside of requests", []string{"project", "region", "name", "resource_type"}, nil)
+	serverlessRequests           = prometheus.NewDesc("serverless_requests", "tells how many requests the Cloud Run service, or executions the Cloud Function, served over the lookback window", []string{"project", "region", "name", "resource_type"}, nil)
+)
+
+const (
+	serverlessResourceTypeRun      = "cloud_run_service"
+	serverlessResourceTypeFunction = "cloud_function"
+)
+
+// serverlessResource identifies a Cloud Run service or Cloud Function by region and name.
+type serverlessResource struct {
+	region string
+	name   string
+}
+
+type ServerlessCollector struct {
+	logger            log.Logger
+	runService        *run.Service
+	functionsService  *cloudfunctions.Service
+	monitoringService *monitoring.Service
+	project           string
+	monitoredRegions  []string
+	lookback          time.Duration
+	pagesFetched      prometheus.Counter
+	excluder          *ResourceExcluder
+	labelsInfo        *ResourceLabelsInfo
+	mutex             sync.RWMutex
+}
+
+func init() {
+	registerCollector("serverless", defaultDisabled, NewServerlessCollector)
+}
+
+func (e *ServerlessCollector) ListMetrics() []string {
+	return []string{"serverless_min_instances", "serverless_cpu_always_allocated", "serverless_requests", "serverless_labels"}
+}
+
+func NewServerlessCollector(logger log.Logger, project string, monitoredRegions []string) (Collector, error) {
+	excluder, err := NewResourceExcluder("serverless", project)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	gcpClient, err := NewGCPClient(ctx, run.CloudPlatformScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	runService, err := run.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	functionsService, err := cloudfunctions.NewService(ctx, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	monitoringClient, err := NewGCPClient(ctx, monitoring.MonitoringReadScope)
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create GCP Client", "err", err)
+	}
+
+	monitoringService, err := monitoring.NewService(ctx, option.WithHTTPClient(monitoringClient))
+	if err != nil {
+		level.Error(logger).Log("msg", "Unable to create service", "err", err)
+	}
+
+	return &ServerlessCollector{
+		logger:            logger,
+		runService:        runService,
+		functionsService:  functionsService,
+		monitoringService: monitoringService,
+		project:           project,
+		monitoredRegions:  monitoredRegions,
+		lookback:          *serverlessLookback,
+		pagesFetched:      apiPagesFetched.WithLabelValues("serverless", project),
+		excluder:          excluder,
+		labelsInfo:        NewResourceLabelsInfo("serverless_labels", "GCP labels of the Cloud Run service or Cloud Function", []string{"project", "region", "name", "resource_type"}),
+	}, nil
+}
+
+func (e *ServerlessCollector) Update(ch chan<- prometheus.Metric) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+
+	// Cloud Run's v2 API can't list services across every location at once.
+	services := map[serverlessResource]*run.GoogleCloudRunV2Service{}
+	functions := map[string][]*cloudfunctions.Function{}
+	for _, region := range e.monitoredRegions {
+		parent := fmt.Sprintf("projects/%s/locations/%s", e.project, region)
+
+		err := e.runService.Projects.Locations.Services.List(parent).Pages(ctx, func(page *run.GoogleCloudRunV2ListServicesResponse) error {
+			e.pagesFetched.Inc()
+			for _, service := range page.Services {
+				services[serverlessResource{region, GetResourceNameFromURL(e.logger, service.Name)}] = service
+			}
+			return nil
+		})
+		if err != nil {
+			level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting Cloud Run services for project %s in region %s", e.project, region), "err", err)
+		}
+
+		err = e.functionsService.Projects.Locations.Functions.List(parent).Pages(ctx, func(page *cloudfunctions.ListFunctionsResponse) error {
+			e.pagesFetched.Inc()
+			functions[region] = append(functions[region], page.Functions...)
+			return nil
+		})
+		if err != nil {
+			level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting Cloud Functions for project %s in region %s", e.project, region), "err", err)
+		}
+	}
+
+	now := time.Now()
+	requests := e.queryByResource(ctx, "Cloud Run services' requests", MonitoringQuery{
+		Filter:  `metric.type="run.googleapis.com/request_count" AND resource.type="cloud_run_revision"`,
+		Aligner: "ALIGN_SUM",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.location", "resource.label.service_name"},
+	}, "location", "service_name", now)
+	// Only 1st gen functions report executions, 2nd gen ones report the
+	// requests of their Cloud Run service.
+	executions := e.queryByResource(ctx, "Cloud Functions' executions", MonitoringQuery{
+		Filter:  `metric.type="cloudfunctions.googleapis.com/function/execution_count" AND resource.type="cloud_function"`,
+		Aligner: "ALIGN_SUM",
+		Reducer: "REDUCE_SUM",
+		GroupBy: []string{"resource.label.region", "resource.label.function_name"},
+	}, "region", "function_name", now)
+
+	// 2nd gen functions run on a Cloud Run service of their own, which is
+	// reported as part of the function.
+	functionServices := map[serverlessResource]bool{}
+	for region, regionFunctions := range functions {
+		for _, function := range regionFunctions {
+			if function.ServiceConfig == nil {
+				continue
+			}
+
+			var service *run.GoogleCloudRunV2Service
+			if function.ServiceConfig.Service != "" {
+				key := serverlessResource{region, GetResourceNameFromURL(e.logger, function.ServiceConfig.Service)}
+				service = services[key]
+				functionServices[key] = true
+			}
+
+			name := GetResourceNameFromURL(e.logger, function.Name)
+			if e.excluder.Excluded(name, function.Labels) {
+				continue
+			}
+
+			// 1st gen functions are only allocated CPU while executing.
+			var cpuAlwaysAllocated bool
+			if service != nil {
+				cpuAlwaysAllocated = cloudRunCPUAlwaysAllocated(service.Template)
+			}
+			count, ok := functionRequestCount(e.logger, region, function, requests, executions)
+			e.report(ch, serverlessResource{region, name}, serverlessResourceTypeFunction, function.ServiceConfig.MinInstanceCount, cpuAlwaysAllocated, count, ok)
+			e.labelsInfo.Collect(ch, function.Labels, e.project, region, name, serverlessResourceTypeFunction)
+		}
+	}
+
+	for key, service := range services {
+		if functionServices[key] || e.excluder.Excluded(key.name, service.Labels) {
+			continue
+		}
+
+		var minInstances int64
+		if service.Template != nil && service.Template.Scaling != nil {
+			minInstances = service.Template.Scaling.MinInstanceCount
+		}
+		count, ok := serverlessRequestCount(requests, key)
+		e.report(ch, key, serverlessResourceTypeRun, minInstances, cloudRunCPUAlwaysAllocated(service.Template), count, ok)
+		e.labelsInfo.Collect(ch, service.Labels, e.project, key.region, key.name, serverlessResourceTypeRun)
+	}
+
+	return nil
+}
+
+func (e *ServerlessCollector) report(ch chan<- prometheus.Metric, resource serverlessResource, resourceType string, minInstances int64, cpuAlwaysAllocated bool, requests float64, hasRequests bool) {
+	ch <- prometheus.MustNewConstMetric(serverlessMinInstances, prometheus.GaugeValue, float64(minInstances), e.project, resource.region, resource.name, resourceType)
+
+	var cpu float64
+	if cpuAlwaysAllocated {
+		cpu = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(serverlessCPUAlwaysAllocated, prometheus.GaugeValue, cpu, e.project, resource.region, resource.name, resourceType)
+
+	if hasRequests {
+		ch <- prometheus.MustNewConstMetric(serverlessRequests, prometheus.GaugeValue, requests, e.project, resource.region, resource.name, resourceType)
+	}
+}
+
+// serverlessRequestCount returns the requests counted for a resource, unless
+// Cloud Monitoring couldn't be queried. Resources without any request have no
+// time series at all.
+func serverlessRequestCount(samples map[serverlessResource]MonitoringSample, resource serverlessResource) (float64, bool) {
+	if samples == nil {
+		return 0, false
+	}
+	return samples[resource].Value, true
+}
+
+// functionRequestCount returns the requests counted for a Cloud Function. 2nd
+// gen functions report them on their Cloud Run service, while 1st gen
+// functions report executions of their own.
+func functionRequestCount(logger log.Logger, region string, function *cloudfunctions.Function, requests map[serverlessResource]MonitoringSample, executions map[serverlessResource]MonitoringSample) (float64, bool) {
+	if function.ServiceConfig != nil && function.ServiceConfig.Service != "" {
+		return serverlessRequestCount(requests, serverlessResource{region, GetResourceNameFromURL(logger, function.ServiceConfig.Service)})
+	}
+	if function.Environment == "GEN_1" {
+		return serverlessRequestCount(executions, serverlessResource{region, GetResourceNameFromURL(logger, function.Name)})
+	}
+	return 0, false
+}
+
+// queryByResource returns the samples of a Cloud Monitoring query by the
+// region and name resource labels, or nil when the query fails.
+func (e *ServerlessCollector) queryByResource(ctx context.Context, what string, query MonitoringQuery, regionLabel string, nameLabel string, now time.Time) map[serverlessResource]MonitoringSample {
+	samples, err := QueryMonitoring(ctx, e.monitoringService, e.project, query, e.lookback, now, e.pagesFetched)
+	if err != nil {
+		level.Error(e.logger).Log("msg", fmt.Sprintf("error requesting %s for project %s", what, e.project), "err", err)
+		return nil
+	}
+
+	byResource := map[serverlessResource]MonitoringSample{}
+	for _, s := range samples {
+		byResource[serverlessResource{s.ResourceLabels[regionLabel], s.ResourceLabels[nameLabel]}] = s
+	}
+	return byResource
+}
+
+// cloudRunCPUAlwaysAllocated tells whether any container of a revision keeps
+// its CPU outside of requests. CPU is only allocated during requests by default.
+func cloudRunCPUAlwaysAllocated(template *run.GoogleCloudRunV2RevisionTemplate) bool {
+	if template == nil {
+		return false
+	}
+	for _, c := range template.Containers {
+		if c.Resources != nil && !c.Resources.CpuIdle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/log"
+	cloudfunctions "google.golang.org/api/cloudfunctions/v2"
+	run "google.golang.org/api/run/v2"
+)
+
+func TestServerlessCollectorListMetrics(t *testing.T) {
+	cases := []struct {
+		desc     string
+		expected []string
+	}{
+		{"should list available metrics for serverless collector", []string{"serverless_min_instances", "serverless_cpu_always_allocated", "serverless_requests", "serverless_labels"}},
+	}
+
+	for _, tc := range cases {
+		collector := ServerlessCollector{}
+		if !reflect.DeepEqual(collector.ListMetrics(), tc.expected) {
+			t.Errorf("expected %s got %+v", tc.expected, collector.ListMetrics())
+		}
+	}
+}
+
+func TestCloudRunCPUAlwaysAllocated(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    *run.GoogleCloudRunV2RevisionTemplate
+		expected bool
+	}{
+		{"should tell a missing template", nil, false},
+		{"should tell containers without resources", &run.GoogleCloudRunV2RevisionTemplate{Containers: []*run.GoogleCloudRunV2Container{{}}}, false},
+		{
+			"should tell CPU allocated during requests only",
+			&run.GoogleCloudRunV2RevisionTemplate{Containers: []*run.GoogleCloudRunV2Container{{Resources: &run.GoogleCloudRunV2ResourceRequirements{CpuIdle: true}}}},
+			false,
+		},
+		{
+			"should tell CPU always allocated to any container",
+			&run.GoogleCloudRunV2RevisionTemplate{Containers: []*run.GoogleCloudRunV2Container{
+				{Resources: &run.GoogleCloudRunV2ResourceRequirements{CpuIdle: true}},
+				{Resources: &run.GoogleCloudRunV2ResourceRequirements{CpuIdle: false}},
+			}},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		if got := cloudRunCPUAlwaysAllocated(tc.input); got != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, got)
+		}
+	}
+}
+
+func TestFunctionRequestCount(t *testing.T) {
+	requests := map[serverlessResource]MonitoringSample{
+		{"us-east1", "gen2-function"}: {Value: 42},
+	}
+	executions := map[serverlessResource]MonitoringSample{
+		{"us-east1", "gen1-function"}: {Value: 7},
+	}
+
+	cases := []struct {
+		desc       string
+		input      *cloudfunctions.Function
+		requests   map[serverlessResource]MonitoringSample
+		executions map[serverlessResource]MonitoringSample
+		expected   float64
+		expectedOk bool
+	}{
+		{
+			"should count the requests of a 2nd gen function's Cloud Run service",
+			&cloudfunctions.Function{
+				Name:          "projects/p/locations/us-east1/functions/gen2-function",
+				Environment:   "GEN_2",
+				ServiceConfig: &cloudfunctions.ServiceConfig{Service: "projects/p/locations/us-east1/services/gen2-function"},
+			},
+			requests, executions, 42, true,
+		},
+		{
+			"should count no requests of an idle 2nd gen function",
+			&cloudfunctions.Function{
+				Name:          "projects/p/locations/us-east1/functions/idle-function",
+				Environment:   "GEN_2",
+				ServiceConfig: &cloudfunctions.ServiceConfig{Service: "projects/p/locations/us-east1/services/idle-function"},
+			},
+			requests, executions, 0, true,
+		},
+		{
+			"should count the executions of a 1st gen function",
+			&cloudfunctions.Function{
+				Name:          "projects/p/locations/us-east1/functions/gen1-function",
+				Environment:   "GEN_1",
+				ServiceConfig: &cloudfunctions.ServiceConfig{},
+			},
+			requests, executions, 7, true,
+		},
+		{
+			"should count nothing for a 2nd gen function without its Cloud Run service",
+			&cloudfunctions.Function{
+				Name:          "projects/p/locations/us-east1/functions/pending-function",
+				Environment:   "GEN_2",
+				ServiceConfig: &cloudfunctions.ServiceConfig{},
+			},
+			requests, executions, 0, false,
+		},
+		{
+			"should count nothing when Cloud Monitoring couldn't be queried",
+			&cloudfunctions.Function{
+				Name:          "projects/p/locations/us-east1/functions/gen2-function",
+				Environment:   "GEN_2",
+				ServiceConfig: &cloudfunctions.ServiceConfig{Service: "projects/p/locations/us-east1/services/gen2-function"},
+			},
+			nil, executions, 0, false,
+		},
+	}
+
+	for _, tc := range cases {
+		count, ok := functionRequestCount(log.NewNopLogger(), "us-east1", tc.input, tc.requests, tc.executions)
+		if count != tc.expected || ok != tc.expectedOk {
+			t.Errorf("%s want %v, %v got %v, %v instead", tc.desc, tc.expected, tc.expectedOk, count, ok)
+		}
+	}
+}
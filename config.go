@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/7onn/gcp-idleness-exporter/collector"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the content of the --config.file YAML file. Anything it sets
+// overrides the command line.
+type Config struct {
+	Projects       []string                   `yaml:"projects"`
+	Regions        []string                   `yaml:"regions"`
+	ResourceLabels []string                   `yaml:"resource_labels"`
+	Exclude        ExcludeConfig              `yaml:"exclude"`
+	Collectors     map[string]CollectorConfig `yaml:"collectors"`
+}
+
+// ExcludeConfig holds the name regex and label selectors, key=value or key, of
+// the resources which must not be reported.
+type ExcludeConfig struct {
+	NameRegex string   `yaml:"name_regex"`
+	Labels    []string `yaml:"labels"`
+}
+
+// CollectorConfig holds the settings of a single collector. Options and
+// thresholds are named after the collector's flags without their
+// collector.<name>. prefix, e.g. list-strategy.
+type CollectorConfig struct {
+	Enabled *bool             `yaml:"enabled"`
+	Options map[string]string `yaml:"options"`
+	Exclude ExcludeConfig     `yaml:"exclude"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	if err = yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// flagValues translates the configuration into the values of the flags it overrides.
+func (c *Config) flagValues() map[string]string {
+	values := map[string]string{}
+	c.Exclude.flagValues(values, "exclude.")
+
+	for name, cc := range c.Collectors {
+		prefix := fmt.Sprintf("collector.%s", name)
+		if cc.Enabled != nil {
+			values[prefix] = strconv.FormatBool(*cc.Enabled)
+		}
+		for option, value := range cc.Options {
+			values[prefix+"."+option] = value
+		}
+		cc.Exclude.flagValues(values, prefix+".exclude.")
+	}
+
+	return values
+}
+
+func (e ExcludeConfig) flagValues(values map[string]string, prefix string) {
+	if e.NameRegex != "" {
+		values[prefix+"name-regex"] = e.NameRegex
+	}
+	if len(e.Labels) > 0 {
+		values[prefix+"labels"] = strings.Join(e.Labels, ",")
+	}
+}
+
+// configReloader applies the configuration file on startup and whenever it is reloaded.
+type configReloader struct {
+	file   string
+	logger log.Logger
+
+	mutex sync.Mutex
+	// current is the last configuration which has been applied successfully.
+	current *Config
+	// commandLine holds the command line values of the flags the configuration can override.
+	commandLine         map[string]string
+	commandLineProjects []string
+	commandLineRegions  []string
+	commandLineLabels   []string
+
+	lastReloadSuccessful prometheus.Gauge
+	lastReloadSuccess    prometheus.Gauge
+}
+
+func newConfigReloader(file string, logger log.Logger) *configReloader {
+	r := &configReloader{
+		file:        file,
+		logger:      logger,
+		commandLine: map[string]string{},
+		lastReloadSuccessful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gcp_idleness_exporter_config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful.",
+		}),
+		lastReloadSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gcp_idleness_exporter_config_last_reload_success_timestamp_seconds",
+			Help: "Timestamp of the last successful configuration reload.",
+		}),
+	}
+
+	for _, f := range kingpin.CommandLine.Model().Flags {
+		if f.Name == "collector.disable-defaults" {
+			continue
+		}
+		if strings.HasPrefix(f.Name, "collector.") || strings.HasPrefix(f.Name, "exclude.") {
+			r.commandLine[f.Name] = f.Value.String()
+		}
+	}
+
+	settingsMtx.RLock()
+	r.commandLineProjects = monitoredProjects
+	r.commandLineRegions = monitoredRegions
+	r.commandLineLabels = collector.ResourceLabels
+	settingsMtx.RUnlock()
+
+	return r
+}
+
+// Reload reads the configuration file and applies it. The previous
+// configuration is kept when the file is invalid.
+func (r *configReloader) Reload() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.reload()
+	if err != nil {
+		level.Error(r.logger).Log("msg", "couldn't reload configuration", "file", r.file, "err", err)
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	level.Info(r.logger).Log("msg", "configuration reloaded", "file", r.file)
+	r.lastReloadSuccessful.Set(1)
+	r.lastReloadSuccess.SetToCurrentTime()
+	return nil
+}
+
+func (r *configReloader) reload() error {
+	c, err := loadConfig(r.file)
+	if err != nil {
+		return err
+	}
+
+	if err = collector.Reconfigure(func() error { return r.apply(c) }); err != nil {
+		if r.current != nil {
+			if rollbackErr := collector.Reconfigure(func() error { return r.apply(r.current) }); rollbackErr != nil {
+				level.Error(r.logger).Log("msg", "couldn't restore previous configuration", "err", rollbackErr)
+			}
+		}
+		return err
+	}
+
+	r.current = c
+	return nil
+}
+
+// apply resets every overridable setting to its command line value, then
+// applies c on top of it.
+func (r *configReloader) apply(c *Config) error {
+	for name, value := range r.commandLine {
+		if err := setFlag(name, value); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range c.flagValues() {
+		if _, ok := r.commandLine[name]; !ok {
+			return fmt.Errorf("unknown setting %s", name)
+		}
+		if err := setFlag(name, value); err != nil {
+			return err
+		}
+	}
+
+	if err := collector.ValidateExclusions(); err != nil {
+		return err
+	}
+
+	settingsMtx.Lock()
+	defer settingsMtx.Unlock()
+
+	monitoredProjects = r.commandLineProjects
+	if len(c.Projects) > 0 {
+		monitoredProjects = c.Projects
+	}
+	monitoredRegions = r.commandLineRegions
+	if len(c.Regions) > 0 {
+		monitoredRegions = c.Regions
+	}
+	collector.ResourceLabels = r.commandLineLabels
+	if len(c.ResourceLabels) > 0 {
+		collector.ResourceLabels = c.ResourceLabels
+	}
+
+	return nil
+}
+
+func setFlag(name string, value string) error {
+	f := kingpin.CommandLine.GetFlag(name)
+	if f == nil {
+		return fmt.Errorf("unknown flag %s", name)
+	}
+	if err := f.Model().Value.Set(value); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+	}
+	return nil
+}
+
+// reloadOn reloads the configuration whenever a signal is received, until signals is closed.
+func (r *configReloader) reloadOn(signals <-chan os.Signal) {
+	for range signals {
+		r.Reload()
+	}
+}
+
+// ServeHTTP implements http.Handler for the /-/reload endpoint.
+func (r *configReloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.Reload(); err != nil {
+		http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
+	}
+}
+
+func (r *configReloader) collectors() []prometheus.Collector {
+	return []prometheus.Collector{r.lastReloadSuccessful, r.lastReloadSuccess}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/7onn/gcp-idleness-exporter/collector"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigFlagValues(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected map[string]string
+	}{
+		{"should override nothing", "projects: [a]", map[string]string{}},
+		{
+			"should translate collectors and exclusions into flags",
+			`
+exclude:
+  name_regex: dr-.*
+  labels: [purpose=standby, keep]
+collectors:
+  gce_is_disk_attached:
+    enabled: false
+    options:
+      list-strategy: zonal
+    exclude:
+      name_regex: backup-.*
+`,
+			map[string]string{
+				"exclude.name-regex":                                "dr-.*",
+				"exclude.labels":                                    "purpose=standby,keep",
+				"collector.gce_is_disk_attached":                    "false",
+				"collector.gce_is_disk_attached.list-strategy":      "zonal",
+				"collector.gce_is_disk_attached.exclude.name-regex": "backup-.*",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		c := &Config{}
+		if err := yaml.UnmarshalStrict([]byte(tc.input), c); err != nil {
+			t.Fatalf("%s: %v", tc.desc, err)
+		}
+		if r := c.flagValues(); !reflect.DeepEqual(r, tc.expected) {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+
+	pb := &dto.Metric{}
+	if err := g.Write(pb); err != nil {
+		t.Fatal(err)
+	}
+	return pb.GetGauge().GetValue()
+}
+
+func TestConfigReloader(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--regions", "us-east1"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func(regions []string) { monitoredRegions = regions }(monitoredRegions)
+	monitoredRegions = []string{"us-east1"}
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := ioutil.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listStrategy := kingpin.CommandLine.GetFlag("collector.gce_is_disk_attached.list-strategy").Model().Value
+	nameRegex := kingpin.CommandLine.GetFlag("exclude.name-regex").Model().Value
+
+	r := newConfigReloader(file, log.NewNopLogger())
+	defer collector.Reconfigure(func() error { return r.apply(&Config{}) })
+
+	write(`
+regions: [europe-west1]
+collectors:
+  gce_is_disk_attached:
+    options:
+      list-strategy: zonal
+`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("should reload through POST /-/reload, got %d %s", w.Code, w.Body.String())
+	}
+	if listStrategy.String() != "zonal" || !reflect.DeepEqual(monitoredRegions, []string{"europe-west1"}) {
+		t.Errorf("should apply the configuration, got list-strategy %s and regions %v", listStrategy, monitoredRegions)
+	}
+	if gaugeValue(t, r.lastReloadSuccessful) != 1 {
+		t.Errorf("should tell the reload was successful")
+	}
+	lastSuccess := gaugeValue(t, r.lastReloadSuccess)
+	if lastSuccess == 0 {
+		t.Errorf("should tell when the reload succeeded")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/-/reload", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("should only reload on POST, got %d", w.Code)
+	}
+
+	// The invalid regex only fails once every flag has been set.
+	write(`
+regions: [asia-east1]
+exclude:
+  name_regex: "("
+collectors:
+  gce_is_disk_attached:
+    options:
+      list-strategy: aggregated
+`)
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGHUP
+	close(signals)
+	r.reloadOn(signals)
+
+	if listStrategy.String() != "zonal" || nameRegex.String() != "" || !reflect.DeepEqual(monitoredRegions, []string{"europe-west1"}) {
+		t.Errorf("should roll back to the previous configuration, got list-strategy %s, name regex %s and regions %v", listStrategy, nameRegex, monitoredRegions)
+	}
+	if gaugeValue(t, r.lastReloadSuccessful) != 0 {
+		t.Errorf("should tell the reload failed")
+	}
+	if gaugeValue(t, r.lastReloadSuccess) != lastSuccess {
+		t.Errorf("should keep the time of the last successful reload")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("should fail to reload an invalid configuration through POST /-/reload, got %d", w.Code)
+	}
+}
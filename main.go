@@ -6,8 +6,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 	"os/user"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 
 	stdlog "log"
 
@@ -17,26 +21,68 @@ import (
 
 	"cloud.google.com/go/compute/metadata"
 	"github.com/7onn/gcp-idleness-exporter/collector"
+	"github.com/7onn/gcp-idleness-exporter/pricing"
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
+	"github.com/samber/lo"
 	"github.com/tidwall/gjson"
+	"google.golang.org/api/cloudbilling/v1"
+	"google.golang.org/api/option"
 )
 
 var (
 	gcpProjectID = kingpin.Flag(
-		"project-id", "GCP Project ID to monitor. ($GCP_PROJECT_ID)",
+		"project-id", "Comma-separated GCP Project IDs to monitor. e.g: project-a,project-b ($GCP_PROJECT_ID)",
 	).Envar("GCP_PROJECT_ID").String()
 
+	gcpProjectIDFile = kingpin.Flag(
+		"project-id-file", "Path to a file listing GCP Project IDs to monitor, one per line. ($GCP_PROJECT_ID_FILE)",
+	).Envar("GCP_PROJECT_ID_FILE").String()
+
+	monitoredProjects []string
+	// detectedProjects holds the project found in the credentials or the metadata
+	// server, used when no project is configured.
+	detectedProjects []string
+
+	discoveryFolderIDs = kingpin.Flag(
+		"discovery.folder-ids", "Comma-separated folder IDs whose ACTIVE projects, including those in nested folders, should be monitored. ($GCP_EXPORTER_DISCOVERY_FOLDER_IDS)",
+	).Envar("GCP_EXPORTER_DISCOVERY_FOLDER_IDS").String()
+
+	discoveryOrganizationID = kingpin.Flag(
+		"discovery.organization-id", "Organization ID whose ACTIVE projects should be monitored. ($GCP_EXPORTER_DISCOVERY_ORGANIZATION_ID)",
+	).Envar("GCP_EXPORTER_DISCOVERY_ORGANIZATION_ID").String()
+
+	discoveryIncludeRegex = kingpin.Flag(
+		"discovery.include-regex", "Only monitor discovered projects whose ID fully matches this regex. ($GCP_EXPORTER_DISCOVERY_INCLUDE_REGEX)",
+	).Envar("GCP_EXPORTER_DISCOVERY_INCLUDE_REGEX").String()
+
+	discoveryExcludeRegex = kingpin.Flag(
+		"discovery.exclude-regex", "Don't monitor discovered projects whose ID fully matches this regex. ($GCP_EXPORTER_DISCOVERY_EXCLUDE_REGEX)",
+	).Envar("GCP_EXPORTER_DISCOVERY_EXCLUDE_REGEX").String()
+
+	discoveryRefreshInterval = kingpin.Flag(
+		"discovery.refresh-interval", "How often discovered projects are listed again. ($GCP_EXPORTER_DISCOVERY_REFRESH_INTERVAL)",
+	).Envar("GCP_EXPORTER_DISCOVERY_REFRESH_INTERVAL").Default("1h").Duration()
+
+	projectDiscoverer *collector.ProjectDiscoverer
+
 	gcpRegions = kingpin.Flag(
 		"regions", "Comma-separated GCP regions to monitor. e.g: asia-east1,southamerica-east1,us-east1 ($GCP_REGIONS)",
 	).Envar("GCP_REGIONS").String()
 
 	monitoredRegions []string
 
+	configFile = kingpin.Flag(
+		"config.file", "Path to a YAML configuration file overriding the command line. Reloaded on SIGHUP or POST /-/reload. ($GCP_EXPORTER_CONFIG_FILE)",
+	).Envar("GCP_EXPORTER_CONFIG_FILE").String()
+
+	// settingsMtx guards the settings which can change on configuration reload.
+	settingsMtx sync.RWMutex
+
 	gcpMaxRetries = kingpin.Flag(
 		"max-retries", "Max number of retries that should be attempted on 503 errors from gcp. ($GCP_EXPORTER_MAX_RETRIES)\n",
 	).Envar("GCP_EXPORTER_MAX_RETRIES").Default("0").Int()
@@ -57,6 +103,34 @@ var (
 		"retry-statuses", "The HTTP statuses that should trigger a retry ($GCP_EXPORTER_RETRY_STATUSES)",
 	).Envar("GCP_EXPORTER_RETRY_STATUSES").Default("503").Ints()
 
+	collectionInterval = kingpin.Flag(
+		"collection-interval", "How often metrics are collected in the background and cached for /metrics. Zero collects on every scrape ($GCP_EXPORTER_COLLECTION_INTERVAL)",
+	).Envar("GCP_EXPORTER_COLLECTION_INTERVAL").Default("0s").Duration()
+
+	resourceLabels = kingpin.Flag(
+		"resource-labels", "Comma-separated GCP resource labels exported as label_<name> on the *_labels info metrics. e.g: team,env ($GCP_EXPORTER_RESOURCE_LABELS)",
+	).Envar("GCP_EXPORTER_RESOURCE_LABELS").String()
+
+	pricingEnabled = kingpin.Flag(
+		"pricing.enabled", "Estimate how much idle resources cost per month. ($GCP_EXPORTER_PRICING_ENABLED)",
+	).Envar("GCP_EXPORTER_PRICING_ENABLED").Default("true").Bool()
+
+	pricingFile = kingpin.Flag(
+		"pricing.file", "Path to a YAML price table overriding the bundled one. The cloud-billing backend falls back on it for prices missing from the catalog. ($GCP_EXPORTER_PRICING_FILE)",
+	).Envar("GCP_EXPORTER_PRICING_FILE").String()
+
+	pricingBackend = kingpin.Flag(
+		"pricing.backend", "Where prices come from: static for the price table, cloud-billing for the Cloud Billing Catalog API. ($GCP_EXPORTER_PRICING_BACKEND)",
+	).Envar("GCP_EXPORTER_PRICING_BACKEND").Default("static").Enum("static", "cloud-billing")
+
+	pricingCacheFile = kingpin.Flag(
+		"pricing.cache-file", "Path to the file caching the prices of the cloud-billing backend. ($GCP_EXPORTER_PRICING_CACHE_FILE)",
+	).Envar("GCP_EXPORTER_PRICING_CACHE_FILE").Default(filepath.Join(os.TempDir(), "gcp-idleness-exporter-prices.yaml")).String()
+
+	pricingRefreshInterval = kingpin.Flag(
+		"pricing.refresh-interval", "How often the cloud-billing backend fetches prices again. ($GCP_EXPORTER_PRICING_REFRESH_INTERVAL)",
+	).Envar("GCP_EXPORTER_PRICING_REFRESH_INTERVAL").Default("24h").Duration()
+
 	disableDefaultCollectors = kingpin.Flag(
 		"collector.disable-defaults",
 		"Set all collectors to disabled by default.",
@@ -65,12 +139,16 @@ var (
 
 type MetricsHandler struct {
 	exporterMetricsRegistry *prometheus.Registry
+	cachedCollector         *collector.CachedCollector
 	logger                  log.Logger
 }
 
-func newMetricsHandler(logger log.Logger) *MetricsHandler {
+// newMetricsHandler creates a MetricsHandler. When cachedCollector is not nil
+// its snapshot is served instead of collecting GCP metrics on every scrape.
+func newMetricsHandler(logger log.Logger, cachedCollector *collector.CachedCollector) *MetricsHandler {
 	h := &MetricsHandler{
 		exporterMetricsRegistry: prometheus.NewRegistry(),
+		cachedCollector:         cachedCollector,
 		logger:                  logger,
 	}
 	h.exporterMetricsRegistry.MustRegister(
@@ -83,21 +161,30 @@ func newMetricsHandler(logger log.Logger) *MetricsHandler {
 
 // ServeHTTP implements http.Handler.
 func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	gcpCollector, err := collector.NewGCPCollector(ctx, h.logger, *gcpProjectID, monitoredRegions)
-	if err != nil {
-		level.Error(h.logger).Log("msg", "couldn't create collector", "err", err)
-	}
-
-	for n, c := range gcpCollector.Collectors {
-		level.Info(h.logger).Log("collector", n, "metrics", fmt.Sprintf("%+v", c.ListMetrics()))
-	}
-
 	pr := prometheus.NewRegistry()
 	pr.MustRegister(version.NewCollector("gcp_idleness_exporter"))
-	if err = pr.Register(gcpCollector); err != nil {
-		level.Error(h.logger).Log("msg", "couldn't register gcp_idleness_exporter collector", "err", err)
+
+	if h.cachedCollector != nil {
+		if err := pr.Register(h.cachedCollector); err != nil {
+			level.Error(h.logger).Log("msg", "couldn't register gcp_idleness_exporter cached collector", "err", err)
+		}
+	} else {
+		gcpCollector, err := newGCPCollector(h.logger)
+		if err != nil {
+			level.Error(h.logger).Log("msg", "couldn't create collector", "err", err)
+		} else {
+			for n, projectCollectors := range gcpCollector.Collectors {
+				for p, c := range projectCollectors {
+					level.Info(h.logger).Log("collector", n, "project", p, "metrics", fmt.Sprintf("%+v", c.ListMetrics()))
+				}
+			}
+
+			if err = pr.Register(gcpCollector); err != nil {
+				level.Error(h.logger).Log("msg", "couldn't register gcp_idleness_exporter collector", "err", err)
+			}
+		}
 	}
+
 	handler := promhttp.HandlerFor(
 		prometheus.Gatherers{h.exporterMetricsRegistry, pr},
 		promhttp.HandlerOpts{
@@ -111,6 +198,86 @@ func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	handler.ServeHTTP(w, r)
 }
 
+func newGCPCollector(logger log.Logger) (*collector.GCPCollector, error) {
+	settingsMtx.RLock()
+	regions := monitoredRegions
+	settingsMtx.RUnlock()
+
+	return collector.NewGCPCollector(context.Background(), logger, currentProjects(), regions)
+}
+
+// currentProjects returns the explicitly configured projects along with the
+// ones found by project discovery, if enabled.
+func currentProjects() []string {
+	settingsMtx.RLock()
+	defer settingsMtx.RUnlock()
+
+	if projectDiscoverer != nil {
+		return lo.Uniq(append(append([]string{}, monitoredProjects...), projectDiscoverer.Projects()...))
+	}
+	if len(monitoredProjects) == 0 {
+		return detectedProjects
+	}
+	return monitoredProjects
+}
+
+// discoveryParents returns the Resource Manager parents to discover projects under.
+func discoveryParents() []string {
+	parents := []string{}
+	for _, f := range parseList(*discoveryFolderIDs) {
+		parents = append(parents, "folders/"+strings.TrimPrefix(f, "folders/"))
+	}
+	if *discoveryOrganizationID != "" {
+		parents = append(parents, "organizations/"+strings.TrimPrefix(*discoveryOrganizationID, "organizations/"))
+	}
+	return parents
+}
+
+// newPricer creates the pricing backend selected by --pricing.backend, falling
+// back on the price table when Cloud Billing prices can't be loaded.
+func newPricer(logger log.Logger) (pricing.Pricer, error) {
+	static, err := pricing.NewStaticPricer(*pricingFile)
+	if err != nil {
+		return nil, err
+	}
+	if *pricingBackend == "static" {
+		return static, nil
+	}
+
+	ctx := context.Background()
+	gcpClient, err := collector.NewGCPClient(ctx, cloudbilling.CloudBillingReadonlyScope)
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't create Cloud Billing client, using the price table", "err", err)
+		return static, nil
+	}
+
+	pricer, err := pricing.NewCloudBillingPricer(ctx, log.With(logger, "component", "pricing"), *pricingCacheFile, *pricingRefreshInterval, static, option.WithHTTPClient(gcpClient))
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't fetch prices from Cloud Billing, using the price table", "err", err)
+		return static, nil
+	}
+	go pricer.Run(ctx)
+	return pricer, nil
+}
+
+// parseList splits a comma or newline separated list, e.g. of project IDs,
+// ignoring blank entries and lines starting with #.
+func parseList(s string) []string {
+	projects := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, p := range strings.Split(line, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				projects = append(projects, p)
+			}
+		}
+	}
+	return projects
+}
+
 func main() {
 	var (
 		listenAddress = kingpin.Flag("listen-address", "Address to listen on for web interface and telemetry.").Envar("LISTEN_ADDRESS").Default(":5000").String()
@@ -128,6 +295,7 @@ func main() {
 	collector.GCPRetryStatuses = *gcpRetryStatuses
 	collector.GCPBackoffJitterBase = *gcpBackoffJitterBase
 	collector.GCPMaxBackoffDuration = *gcpMaxBackoffDuration
+	collector.ResourceLabels = parseList(*resourceLabels)
 
 	logger := promlog.New(promlogConfig)
 
@@ -140,8 +308,56 @@ func main() {
 		level.Warn(logger).Log("msg", "gcp-idleness-exporter is running as root user. This exporter is designed to run as unpriviledged user, root is not required.")
 	}
 
+	monitoredProjects = parseList(*gcpProjectID)
+	if *gcpProjectIDFile != "" {
+		c, err := ioutil.ReadFile(*gcpProjectIDFile)
+		if err != nil {
+			level.Error(logger).Log("msg", fmt.Sprintf("Unable to read %s", *gcpProjectIDFile), "err", err)
+		}
+
+		monitoredProjects = lo.Uniq(append(monitoredProjects, parseList(string(c))...))
+	}
+
+	if *pricingEnabled {
+		pricer, err := newPricer(logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "couldn't load prices", "backend", *pricingBackend, "err", err)
+			os.Exit(1)
+		}
+		collector.Pricer = pricer
+	}
+
+	monitoredRegions = strings.Split(*gcpRegions, ",")
+
+	var reloader *configReloader
+	if *configFile != "" {
+		reloader = newConfigReloader(*configFile, logger)
+		if err := reloader.Reload(); err != nil {
+			os.Exit(1)
+		}
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go reloader.reloadOn(hup)
+	}
+
+	if parents := discoveryParents(); len(parents) > 0 {
+		d, err := collector.NewProjectDiscoverer(log.With(logger, "component", "project_discovery"), parents, *discoveryIncludeRegex, *discoveryExcludeRegex)
+		if err != nil {
+			level.Error(logger).Log("msg", "couldn't create project discoverer", "err", err)
+			os.Exit(1)
+		}
+		if err = d.Refresh(context.Background()); err != nil {
+			level.Error(logger).Log("msg", "project discovery failed", "err", err)
+		}
+
+		projectDiscoverer = d
+		go projectDiscoverer.Run(context.Background(), *discoveryRefreshInterval)
+	}
+
 	// Detect Project ID
-	if *gcpProjectID == "" {
+	if len(currentProjects()) == 0 && projectDiscoverer == nil {
+		var projectID string
 		credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 		if credentialsFile != "" {
 			c, err := ioutil.ReadFile(credentialsFile)
@@ -154,7 +370,7 @@ func main() {
 				level.Error(logger).Log("msg", fmt.Sprintf("Could not retrieve Project ID from %s", credentialsFile))
 			}
 
-			*gcpProjectID = projectId.String()
+			projectID = projectId.String()
 		} else {
 			// Get project id from metadata
 			client := metadata.NewClient(&http.Client{})
@@ -163,20 +379,38 @@ func main() {
 				level.Error(logger).Log("msg", fmt.Sprintf("error getting GCP project ID from metadata: %+v", err))
 			}
 
-			*gcpProjectID = project_id
+			projectID = project_id
+		}
+
+		if projectID != "" {
+			detectedProjects = []string{projectID}
 		}
 	}
 
-	if *gcpProjectID == "" {
+	if len(currentProjects()) == 0 && projectDiscoverer == nil {
 		level.Error(logger).Log("msg", "GCP Project ID cannot be empty")
 	}
 
-	monitoredRegions = strings.Split(*gcpRegions, ",")
-	level.Info(logger).Log("msg", fmt.Sprintf("Starting exporter for project %s at %v", *gcpProjectID, monitoredRegions))
+	level.Info(logger).Log("msg", fmt.Sprintf("Starting exporter for projects %v at %v", currentProjects(), monitoredRegions))
 
 	level.Info(logger).Log("msg", fmt.Sprintf("Listening on %s", *listenAddress))
 
-	http.Handle("/metrics", newMetricsHandler(logger))
+	var cachedCollector *collector.CachedCollector
+	if *collectionInterval > 0 {
+		level.Info(logger).Log("msg", fmt.Sprintf("Collecting metrics in the background every %s", *collectionInterval))
+		cachedCollector = collector.NewCachedCollector(logger, *collectionInterval, func() (prometheus.Collector, error) {
+			return newGCPCollector(logger)
+		})
+		go cachedCollector.Run(context.Background())
+	}
+
+	metricsHandler := newMetricsHandler(logger, cachedCollector)
+	http.Handle("/metrics", metricsHandler)
+
+	if reloader != nil {
+		metricsHandler.exporterMetricsRegistry.MustRegister(reloader.collectors()...)
+		http.Handle("/-/reload", reloader)
+	}
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("aaa aaa aaa aaa staying alive staying alive"))
@@ -0,0 +1,262 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/api/cloudbilling/v1"
+	"google.golang.org/api/option"
+	"gopkg.in/yaml.v2"
+)
+
+// computeEngineService is the Cloud Billing Catalog name of the Compute Engine service.
+const computeEngineService = "services/6F81-5844-456A"
+
+// skuPrice tells which price of a PriceTable an on-demand SKU sets. SKUs are
+// matched on the beginning of their description, which goes on with the
+// location for most regions, e.g. "Storage PD Capacity in Sao Paulo".
+type skuPrice struct {
+	descriptionPrefix string
+	usageUnit         string
+	set               func(prices *RegionPrices, price float64)
+}
+
+var skuPrices = []skuPrice{
+	{"Storage PD Capacity", "GiBy.mo", setDiskGBMonth("pd-standard")},
+	{"Balanced PD Capacity", "GiBy.mo", setDiskGBMonth("pd-balanced")},
+	{"SSD backed PD Capacity", "GiBy.mo", setDiskGBMonth("pd-ssd")},
+	{"Extreme PD Capacity", "GiBy.mo", setDiskGBMonth("pd-extreme")},
+	{"Storage PD Snapshot", "GiBy.mo", func(prices *RegionPrices, price float64) { prices.SnapshotGBMonth = &price }},
+	{"Static Ip Charge", "h", func(prices *RegionPrices, price float64) { prices.StaticIPHour = &price }},
+}
+
+func setDiskGBMonth(diskType string) func(prices *RegionPrices, price float64) {
+	return func(prices *RegionPrices, price float64) {
+		if prices.DiskGBMonth == nil {
+			prices.DiskGBMonth = map[string]float64{}
+		}
+		prices.DiskGBMonth[diskType] = price
+	}
+}
+
+// cachedPriceTable is the content of the cache file of a CloudBillingPricer.
+type cachedPriceTable struct {
+	FetchedAt time.Time  `yaml:"fetched_at"`
+	Prices    PriceTable `yaml:"prices"`
+}
+
+// CloudBillingPricer implements Pricer with the prices of the Cloud Billing
+// Catalog API, cached on disk. Prices missing from the catalog are looked up
+// in the fallback Pricer, if any.
+type CloudBillingPricer struct {
+	service   *cloudbilling.APIService
+	cacheFile string
+	maxAge    time.Duration
+	fallback  Pricer
+	logger    log.Logger
+
+	mutex     sync.RWMutex
+	table     *PriceTable
+	fetchedAt time.Time
+}
+
+// NewCloudBillingPricer creates a CloudBillingPricer using the prices cached in
+// cacheFile when they are fresher than maxAge, or else fetching them from the
+// Cloud Billing Catalog API. A stale cache is used when the API can't be reached.
+func NewCloudBillingPricer(ctx context.Context, logger log.Logger, cacheFile string, maxAge time.Duration, fallback Pricer, opts ...option.ClientOption) (*CloudBillingPricer, error) {
+	service, err := cloudbilling.NewService(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &CloudBillingPricer{
+		service:   service,
+		cacheFile: cacheFile,
+		maxAge:    maxAge,
+		fallback:  fallback,
+		logger:    logger,
+	}
+
+	cached, err := p.loadCache()
+	if err != nil {
+		level.Debug(logger).Log("msg", "couldn't load cached prices", "file", cacheFile, "err", err)
+	} else if time.Since(cached.FetchedAt) < maxAge {
+		p.table, p.fetchedAt = &cached.Prices, cached.FetchedAt
+		return p, nil
+	}
+
+	if err = p.Refresh(ctx); err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		level.Warn(logger).Log("msg", "couldn't fetch prices, using stale cache", "fetched_at", cached.FetchedAt, "err", err)
+		p.table, p.fetchedAt = &cached.Prices, cached.FetchedAt
+	}
+	return p, nil
+}
+
+// Run fetches the prices again once they are maxAge old, then every maxAge,
+// until ctx is done.
+func (p *CloudBillingPricer) Run(ctx context.Context) {
+	timer := time.NewTimer(p.untilStale())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := p.Refresh(ctx); err != nil {
+				level.Error(p.logger).Log("msg", "couldn't refresh prices, keeping previous ones", "err", err)
+			}
+			timer.Reset(p.maxAge)
+		}
+	}
+}
+
+// untilStale returns how long until the prices are maxAge old.
+func (p *CloudBillingPricer) untilStale() time.Duration {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if d := p.maxAge - time.Since(p.fetchedAt); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Refresh fetches the prices from the Cloud Billing Catalog API and caches them.
+func (p *CloudBillingPricer) Refresh(ctx context.Context) error {
+	table, err := p.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("error requesting Compute Engine SKUs: %w", err)
+	}
+
+	p.mutex.Lock()
+	p.table, p.fetchedAt = table, time.Now()
+	p.mutex.Unlock()
+
+	if err = p.saveCache(table); err != nil {
+		level.Error(p.logger).Log("msg", "couldn't cache prices", "file", p.cacheFile, "err", err)
+	}
+	return nil
+}
+
+func (p *CloudBillingPricer) fetch(ctx context.Context) (*PriceTable, error) {
+	table := &PriceTable{Regions: map[string]RegionPrices{}}
+
+	err := p.service.Services.Skus.List(computeEngineService).CurrencyCode("USD").Pages(ctx, func(page *cloudbilling.ListSkusResponse) error {
+		for _, sku := range page.Skus {
+			if sku.Category == nil || sku.Category.UsageType != "OnDemand" {
+				continue
+			}
+
+			for _, s := range skuPrices {
+				if !strings.HasPrefix(sku.Description, s.descriptionPrefix) {
+					continue
+				}
+
+				price, ok := unitPrice(sku, s.usageUnit)
+				if !ok {
+					break
+				}
+				for _, region := range sku.ServiceRegions {
+					prices := table.Regions[region]
+					s.set(&prices, price)
+					table.Regions[region] = prices
+				}
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// unitPrice returns the current price of a SKU past its free tier, if it is
+// billed in usageUnit.
+func unitPrice(sku *cloudbilling.Sku, usageUnit string) (float64, bool) {
+	if len(sku.PricingInfo) == 0 {
+		return 0, false
+	}
+
+	expression := sku.PricingInfo[len(sku.PricingInfo)-1].PricingExpression
+	if expression == nil || expression.UsageUnit != usageUnit || len(expression.TieredRates) == 0 {
+		return 0, false
+	}
+
+	rate := expression.TieredRates[len(expression.TieredRates)-1].UnitPrice
+	if rate == nil {
+		return 0, false
+	}
+	return float64(rate.Units) + float64(rate.Nanos)/1e9, true
+}
+
+func (p *CloudBillingPricer) loadCache() (*cachedPriceTable, error) {
+	if p.cacheFile == "" {
+		return nil, errors.New("no cache file")
+	}
+
+	content, err := ioutil.ReadFile(p.cacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &cachedPriceTable{}
+	if err = yaml.UnmarshalStrict(content, cached); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", p.cacheFile, err)
+	}
+	return cached, nil
+}
+
+func (p *CloudBillingPricer) saveCache(table *PriceTable) error {
+	if p.cacheFile == "" {
+		return nil
+	}
+
+	content, err := yaml.Marshal(cachedPriceTable{FetchedAt: time.Now(), Prices: *table})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.cacheFile, content, 0o644)
+}
+
+// lookup returns the price computed by price with the catalog prices, or with
+// the fallback Pricer when the catalog doesn't have it.
+func (p *CloudBillingPricer) lookup(price func(Pricer) (float64, error)) (float64, error) {
+	p.mutex.RLock()
+	table := p.table
+	p.mutex.RUnlock()
+
+	v, err := price(table)
+	if errors.Is(err, ErrNoPrice) && p.fallback != nil {
+		return price(p.fallback)
+	}
+	return v, err
+}
+
+// DiskGBMonth implements the Pricer interface.
+func (p *CloudBillingPricer) DiskGBMonth(region string, diskType string) (float64, error) {
+	return p.lookup(func(pricer Pricer) (float64, error) { return pricer.DiskGBMonth(region, diskType) })
+}
+
+// SnapshotGBMonth implements the Pricer interface.
+func (p *CloudBillingPricer) SnapshotGBMonth(location string) (float64, error) {
+	return p.lookup(func(pricer Pricer) (float64, error) { return pricer.SnapshotGBMonth(location) })
+}
+
+// StaticIPMonth implements the Pricer interface.
+func (p *CloudBillingPricer) StaticIPMonth(region string) (float64, error) {
+	return p.lookup(func(pricer Pricer) (float64, error) { return pricer.StaticIPMonth(region) })
+}
@@ -0,0 +1,175 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"google.golang.org/api/option"
+	"gopkg.in/yaml.v2"
+)
+
+const fakeSkusPage1 = `{
+  "skus": [
+    {
+      "description": "Storage PD Capacity in Sao Paulo",
+      "category": {"resourceFamily": "Storage", "resourceGroup": "PDStandard", "usageType": "OnDemand"},
+      "serviceRegions": ["southamerica-east1"],
+      "pricingInfo": [{"pricingExpression": {"usageUnit": "GiBy.mo", "tieredRates": [{"unitPrice": {"currencyCode": "USD", "units": "0", "nanos": 60000000}}]}}]
+    },
+    {
+      "description": "Regional Storage PD Capacity in Sao Paulo",
+      "category": {"resourceFamily": "Storage", "resourceGroup": "PDStandard", "usageType": "OnDemand"},
+      "serviceRegions": ["southamerica-east1"],
+      "pricingInfo": [{"pricingExpression": {"usageUnit": "GiBy.mo", "tieredRates": [{"unitPrice": {"currencyCode": "USD", "units": "0", "nanos": 120000000}}]}}]
+    },
+    {
+      "description": "Commitment v1: Storage PD Capacity in Sao Paulo",
+      "category": {"resourceFamily": "Storage", "resourceGroup": "PDStandard", "usageType": "Commit1Yr"},
+      "serviceRegions": ["southamerica-east1"],
+      "pricingInfo": [{"pricingExpression": {"usageUnit": "GiBy.mo", "tieredRates": [{"unitPrice": {"currencyCode": "USD", "units": "0", "nanos": 30000000}}]}}]
+    }
+  ],
+  "nextPageToken": "page2"
+}`
+
+const fakeSkusPage2 = `{
+  "skus": [
+    {
+      "description": "Static Ip Charge",
+      "category": {"resourceFamily": "Network", "resourceGroup": "IpAddress", "usageType": "OnDemand"},
+      "serviceRegions": ["us-east1", "southamerica-east1"],
+      "pricingInfo": [{"pricingExpression": {"usageUnit": "h", "tieredRates": [
+        {"startUsageAmount": 0, "unitPrice": {"currencyCode": "USD"}},
+        {"startUsageAmount": 1, "unitPrice": {"currencyCode": "USD", "units": "0", "nanos": 10000000}}
+      ]}}]
+    }
+  ]
+}`
+
+// newFakeCatalog serves the Compute Engine SKUs of the Cloud Billing Catalog
+// API, or fails when down is set.
+func newFakeCatalog(t *testing.T, down *bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/v1/services/6F81-5844-456A/skus" || r.URL.Query().Get("currencyCode") != "USD" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("pageToken") == "page2" {
+			w.Write([]byte(fakeSkusPage2))
+			return
+		}
+		w.Write([]byte(fakeSkusPage1))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCloudBillingPricer(t *testing.T) {
+	down := false
+	srv := newFakeCatalog(t, &down)
+	cacheFile := filepath.Join(t.TempDir(), "prices.yaml")
+	fallback, err := NewStaticPricer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewCloudBillingPricer(context.Background(), log.NewNopLogger(), cacheFile, time.Hour, fallback, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc     string
+		price    func() (float64, error)
+		expected float64
+		err      error
+	}{
+		{"should return the catalog disk price", func() (float64, error) { return p.DiskGBMonth("southamerica-east1", "pd-standard") }, 0.06, nil},
+		{"should return the monthly catalog static IP price past the free tier", func() (float64, error) { return p.StaticIPMonth("us-east1") }, 7.3, nil},
+		{"should fall back on prices missing from the catalog", func() (float64, error) { return p.DiskGBMonth("southamerica-east1", "pd-ssd") }, 0.17, nil},
+		{"should fail on unknown disk types", func() (float64, error) { return p.DiskGBMonth("us-east1", "local-ssd") }, 0, ErrNoPrice},
+	}
+
+	for _, tc := range cases {
+		r, err := tc.price()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if math.Abs(r-tc.expected) > 1e-9 {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+
+	down = true
+
+	cached, err := NewCloudBillingPricer(context.Background(), log.NewNopLogger(), cacheFile, time.Hour, nil, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("should load fresh prices from the cache, got %v", err)
+	}
+	if r, err := cached.DiskGBMonth("southamerica-east1", "pd-standard"); err != nil || r != 0.06 {
+		t.Errorf("should return the cached disk price, got %v %v", r, err)
+	}
+
+	stale, err := NewCloudBillingPricer(context.Background(), log.NewNopLogger(), cacheFile, 0, nil, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("should fall back on stale cached prices when the API is down, got %v", err)
+	}
+	if r, err := stale.DiskGBMonth("southamerica-east1", "pd-standard"); err != nil || r != 0.06 {
+		t.Errorf("should return the stale cached disk price, got %v %v", r, err)
+	}
+
+	if _, err = NewCloudBillingPricer(context.Background(), log.NewNopLogger(), "", time.Hour, nil, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication()); err == nil {
+		t.Error("should fail without cache when the API is down")
+	}
+}
+
+func TestCloudBillingPricerRun(t *testing.T) {
+	down := false
+	srv := newFakeCatalog(t, &down)
+	cacheFile := filepath.Join(t.TempDir(), "prices.yaml")
+
+	// The cached prices are almost an hour old, so they are due shortly.
+	fetchedAt := time.Now().Add(-time.Hour + 100*time.Millisecond)
+	content, err := yaml.Marshal(cachedPriceTable{FetchedAt: fetchedAt, Prices: PriceTable{Regions: map[string]RegionPrices{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(cacheFile, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewCloudBillingPricer(context.Background(), log.NewNopLogger(), cacheFile, time.Hour, nil, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := p.untilStale(); d <= 0 || d > 100*time.Millisecond {
+		t.Errorf("should schedule the first refresh once the cached prices are an hour old, got %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, err := p.DiskGBMonth("southamerica-east1", "pd-standard"); err == nil && r == 0.06 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("should refresh the prices once the cached ones are an hour old")
+}
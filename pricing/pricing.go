@@ -0,0 +1,24 @@
+// Package pricing estimates how much idle GCP resources cost.
+package pricing
+
+import (
+	"errors"
+)
+
+// HoursPerMonth is the number of hours GCP bills in a month.
+const HoursPerMonth = 730
+
+// ErrNoPrice indicates no price is known for a resource.
+var ErrNoPrice = errors.New("no price known")
+
+// Pricer is the interface a pricing backend has to implement. Prices are in USD.
+type Pricer interface {
+	// DiskGBMonth returns the monthly price of one GB of a disk type, e.g. pd-ssd, in a region.
+	DiskGBMonth(region string, diskType string) (float64, error)
+
+	// SnapshotGBMonth returns the monthly price of one GB of snapshot storage in a location.
+	SnapshotGBMonth(location string) (float64, error)
+
+	// StaticIPMonth returns the monthly price of a static IP address which is not in use in a region.
+	StaticIPMonth(region string) (float64, error)
+}
@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed prices.yaml
+var bundledPriceTable []byte
+
+// PriceTable holds the default prices along with the ones of the regions
+// which differ from them.
+type PriceTable struct {
+	Default RegionPrices            `yaml:"default"`
+	Regions map[string]RegionPrices `yaml:"regions"`
+}
+
+// RegionPrices holds the prices of a region. Unset prices fall back to the default ones.
+type RegionPrices struct {
+	DiskGBMonth     map[string]float64 `yaml:"disk_gb_month"`
+	SnapshotGBMonth *float64           `yaml:"snapshot_gb_month"`
+	StaticIPHour    *float64           `yaml:"static_ip_hour"`
+}
+
+// StaticPricer implements Pricer with a price table file.
+type StaticPricer struct {
+	table PriceTable
+}
+
+// NewStaticPricer creates a StaticPricer from the price table file at path, or
+// from the bundled price table when path is empty.
+func NewStaticPricer(path string) (*StaticPricer, error) {
+	content := bundledPriceTable
+	if path != "" {
+		var err error
+		if content, err = ioutil.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &StaticPricer{}
+	if err := yaml.UnmarshalStrict(content, &p.table); err != nil {
+		return nil, fmt.Errorf("error parsing price table: %w", err)
+	}
+	return p, nil
+}
+
+// DiskGBMonth implements the Pricer interface.
+func (p *StaticPricer) DiskGBMonth(region string, diskType string) (float64, error) {
+	return p.table.DiskGBMonth(region, diskType)
+}
+
+// SnapshotGBMonth implements the Pricer interface.
+func (p *StaticPricer) SnapshotGBMonth(location string) (float64, error) {
+	return p.table.SnapshotGBMonth(location)
+}
+
+// StaticIPMonth implements the Pricer interface.
+func (p *StaticPricer) StaticIPMonth(region string) (float64, error) {
+	return p.table.StaticIPMonth(region)
+}
+
+// DiskGBMonth returns the regional price of a disk type, or the default one.
+func (t *PriceTable) DiskGBMonth(region string, diskType string) (float64, error) {
+	if price, ok := t.Regions[region].DiskGBMonth[diskType]; ok {
+		return price, nil
+	}
+	if price, ok := t.Default.DiskGBMonth[diskType]; ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("%w for %s disks in %s", ErrNoPrice, diskType, region)
+}
+
+// SnapshotGBMonth returns the snapshot storage price of a location, or the default one.
+func (t *PriceTable) SnapshotGBMonth(location string) (float64, error) {
+	if price := t.Regions[location].SnapshotGBMonth; price != nil {
+		return *price, nil
+	}
+	if price := t.Default.SnapshotGBMonth; price != nil {
+		return *price, nil
+	}
+	return 0, fmt.Errorf("%w for snapshots in %s", ErrNoPrice, location)
+}
+
+// StaticIPMonth returns the monthly static IP address price of a region, or the default one.
+func (t *PriceTable) StaticIPMonth(region string) (float64, error) {
+	if price := t.Regions[region].StaticIPHour; price != nil {
+		return *price * HoursPerMonth, nil
+	}
+	if price := t.Default.StaticIPHour; price != nil {
+		return *price * HoursPerMonth, nil
+	}
+	return 0, fmt.Errorf("%w for static IP addresses in %s", ErrNoPrice, region)
+}
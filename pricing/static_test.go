@@ -0,0 +1,72 @@
+package pricing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewStaticPricerBundled(t *testing.T) {
+	p, err := NewStaticPricer("")
+	if err != nil {
+		t.Fatalf("bundled price table should parse, got %v", err)
+	}
+
+	if _, err := p.DiskGBMonth("us-east1", "pd-standard"); err != nil {
+		t.Errorf("bundled price table should price pd-standard disks, got %v", err)
+	}
+	if _, err := p.SnapshotGBMonth("us"); err != nil {
+		t.Errorf("bundled price table should price snapshots, got %v", err)
+	}
+	if _, err := p.StaticIPMonth("us-east1"); err != nil {
+		t.Errorf("bundled price table should price static IP addresses, got %v", err)
+	}
+}
+
+func TestStaticPricer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	err := os.WriteFile(path, []byte(`
+default:
+  disk_gb_month:
+    pd-standard: 0.04
+  static_ip_hour: 0.01
+regions:
+  southamerica-east1:
+    disk_gb_month:
+      pd-standard: 0.06
+    snapshot_gb_month: 0.07
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewStaticPricer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc     string
+		price    func() (float64, error)
+		expected float64
+		err      error
+	}{
+		{"should return the default disk price", func() (float64, error) { return p.DiskGBMonth("us-east1", "pd-standard") }, 0.04, nil},
+		{"should return the regional disk price", func() (float64, error) { return p.DiskGBMonth("southamerica-east1", "pd-standard") }, 0.06, nil},
+		{"should fail on unknown disk types", func() (float64, error) { return p.DiskGBMonth("us-east1", "pd-ssd") }, 0, ErrNoPrice},
+		{"should return the regional snapshot price", func() (float64, error) { return p.SnapshotGBMonth("southamerica-east1") }, 0.07, nil},
+		{"should fail without snapshot price", func() (float64, error) { return p.SnapshotGBMonth("us") }, 0, ErrNoPrice},
+		{"should return the monthly static IP price", func() (float64, error) { return p.StaticIPMonth("us-east1") }, 7.3, nil},
+	}
+
+	for _, tc := range cases {
+		r, err := tc.price()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s want error %v got %v instead", tc.desc, tc.err, err)
+		}
+		if r != tc.expected {
+			t.Errorf("%s want %v got %v instead", tc.desc, tc.expected, r)
+		}
+	}
+}